@@ -2,7 +2,7 @@ Write-Host "正在初始化Go模块..." -ForegroundColor Cyan
 go mod tidy
 chcp 65001 > $null
 Write-Host "正在构建项目..." -ForegroundColor Cyan
-go build -o file-replacer.exe cmd/file-replacer/main.go
+go build -o file-replacer.exe ./cmd/file-replacer
 
 if ($LASTEXITCODE -eq 0) {
     Write-Host "构建成功！" -ForegroundColor Green
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/yourusername/file-replacer/internal/failures"
+	"github.com/yourusername/file-replacer/internal/replacer"
+	"github.com/yourusername/file-replacer/internal/scanner"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// 检查模式的退出码，出错时与替换命令一致使用 exitFatal 和 exitPartial
+const (
+	checkClean   = 0 // 没有任何匹配
+	checkMatched = 1 // 存在匹配
+)
+
+// runCheck 执行 check 子命令: 查找替换项的所有匹配但不修改文件，
+// 逐行输出 "路径:行:列: 匹配文本"，存在匹配时以非零状态退出，可用作 CI 检查
+func runCheck(args []string) {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	cfg, _ := parseConfig(fs, args)
+
+	// 标准输出只保留匹配结果，日志改为输出到标准错误
+	logger.Log.SetOutput(os.Stderr)
+
+	if err := cfg.Validate(); err != nil {
+		fatalf("%v", err)
+	}
+
+	fileScanner := scanner.NewFileScanner(cfg)
+	files, err := fileScanner.Scan()
+	if err != nil {
+		var scanFailures *failures.Error
+		if !errors.As(err, &scanFailures) {
+			fatalf("扫描失败: %v", err)
+		}
+		logger.Log.Warnf("扫描时%v", err)
+	}
+
+	result, err := replacer.NewChecker(cfg).Check(files)
+	if err != nil && result == nil {
+		fatalf("检查失败: %v", err)
+	}
+
+	for _, o := range result.Occurrences {
+		fmt.Printf("%s:%d:%d: %s (替换项 #%d)\n", o.Path, o.Line, o.Column, o.Text, o.Rule)
+	}
+	for _, f := range result.Failed {
+		logger.Log.Warnf("读取文件 %s 时出错: %v", f.Path, f.Err)
+	}
+	// 跳过的文件中可能仍有匹配，逐个列出，以免检查结果被误认为没有问题
+	for _, path := range result.Skipped {
+		logger.Log.Warnf("跳过二进制文件 %s，可使用 -binary 一并检查", path)
+	}
+	if err != nil {
+		logger.Log.Error(err)
+	}
+
+	logger.Log.Infof("检查完成，%d 个文件中共有 %d 处匹配，跳过 %d 个二进制文件",
+		result.Files, len(result.Occurrences), len(result.Skipped))
+
+	switch {
+	case len(result.Occurrences) > 0:
+		os.Exit(checkMatched)
+	case len(result.Failed) > 0 || len(fileScanner.Failures()) > 0:
+		os.Exit(exitPartial)
+	default:
+		os.Exit(checkClean)
+	}
+}
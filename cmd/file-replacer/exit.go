@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// 替换命令的退出码
+const (
+	// exitChanged 有内容被替换（预览模式下为有内容将被替换）
+	exitChanged = 0
+	// exitNoMatch 没有任何匹配
+	exitNoMatch = 1
+	// exitFatal 参数、配置或扫描根目录等致命错误，未执行替换
+	exitFatal = 2
+	// exitPartial 部分文件或路径处理失败，或已处理部分文件后扫描出错
+	exitPartial = 3
+	// exitInterrupted 收到 Ctrl-C 或 SIGTERM 而提前结束
+	exitInterrupted = 130
+)
+
+// fatalf 输出错误日志并以 exitFatal 退出
+func fatalf(format string, args ...interface{}) {
+	logger.Log.Errorf(format, args...)
+	os.Exit(exitFatal)
+}
@@ -0,0 +1,63 @@
+package main
+
+import "strings"
+
+// listFlag 可重复指定的列表参数，split 不为 nil 时每个值还按它分割为多项（如按逗号分割模式列表），
+// 为 nil 时每次只指定一个值，适用于可能含有逗号的路径
+// 第一次出现时会清空已有的值，使命令行参数覆盖配置文件中的列表
+type listFlag struct {
+	target *[]string
+	split  func(string) []string
+	set    bool
+}
+
+func newListFlag(target *[]string, split func(string) []string) *listFlag {
+	return &listFlag{target: target, split: split}
+}
+
+func (l *listFlag) String() string {
+	if l == nil || l.target == nil {
+		return ""
+	}
+	return strings.Join(*l.target, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	if !l.set {
+		*l.target = nil
+		l.set = true
+	}
+	items := []string{value}
+	if l.split != nil {
+		items = l.split(value)
+	}
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			*l.target = append(*l.target, item)
+		}
+	}
+	return nil
+}
+
+// splitPatternList 按逗号分割模式列表，保留 {a,b} 中的逗号
+func splitPatternList(list string) []string {
+	var items []string
+	depth := 0
+	start := 0
+	for i, c := range list {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				items = append(items, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(items, list[start:])
+}
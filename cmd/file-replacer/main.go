@@ -1,32 +1,232 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"os"
+	"os/signal"
+	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/failures"
 	"github.com/yourusername/file-replacer/internal/replacer"
+	"github.com/yourusername/file-replacer/internal/report"
 	"github.com/yourusername/file-replacer/internal/scanner"
 	"github.com/yourusername/file-replacer/pkg/logger"
 )
 
+// options 不属于 config.Config 的命令行参数
+type options struct {
+	// 运行报告的输出目标，未指定时为 nil
+	report *report.Target
+}
+
 func main() {
-	// 解析命令行参数
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "undo":
+			runUndo(os.Args[2:])
+			return
+		case "check":
+			runCheck(os.Args[2:])
+			return
+		case "validate":
+			runValidate(os.Args[2:])
+			return
+		}
+	}
+
+	cfg, opts := parseConfig(flag.CommandLine, os.Args[1:])
+
+	// 差异输出到标准输出时，日志改为输出到标准错误，以便将标准输出直接用作补丁
+	if cfg.DryRun && cfg.Diff && cfg.PatchFile == "" {
+		logger.Log.SetOutput(os.Stderr)
+	}
+
+	// 收到 Ctrl-C 或 SIGTERM 时取消替换：正在处理的文件会完成，剩余文件不再处理
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// 恢复默认的信号处理，再次按 Ctrl-C 可强制退出
+		stop()
+		logger.Log.Warn("收到中断信号，等待正在处理的文件完成后退出，再次按 Ctrl-C 强制退出")
+	}()
+
+	runReplace(ctx, cfg, opts)
+}
+
+// runReplace 扫描目录并执行替换，按结果以不同的退出码退出
+func runReplace(ctx context.Context, cfg *config.Config, opts *options) {
+	if err := cfg.Validate(); err != nil {
+		fatalf("%v", err)
+	}
+
+	// 先创建替换引擎，规则有误时在扫描前退出
+	fileReplacer, err := replacer.NewEngine(cfg)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	// 检查替换项之间的冲突和连锁替换，-strict 时有问题即不执行替换
+	issues, err := replacer.RuleIssues(cfg.ReplaceItems)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			logger.Log.Warn(issue.Message)
+		}
+		if cfg.Strict {
+			fatalf("替换项存在 %d 个问题，已按 -strict 停止，可使用 validate 命令查看", len(issues))
+		}
+	}
+
+	// 扫描与替换同时进行，开始前先确认各根目录都能访问，以免替换了部分根目录后才发现错误
+	fileScanner := scanner.NewFileScanner(cfg)
+	if err := fileScanner.CheckRoots(); err != nil {
+		fatalf("%v", err)
+	}
+	scanCtx, cancelScan := context.WithCancel(ctx)
+	defer cancelScan()
+	files, errc := fileScanner.Stream(scanCtx)
+
+	err = fileReplacer.ReplaceStream(ctx, files)
+	// 替换提前结束（如规则或配置有误）时停止扫描
+	cancelScan()
+	summary := fileReplacer.Summary()
+	scanFailed := false
+	if scanErr := <-errc; scanErr != nil && ctx.Err() == nil {
+		// collect 策略下扫描错误不影响已发现文件的替换
+		var scanFailures *failures.Error
+		switch {
+		case errors.As(scanErr, &scanFailures):
+			logger.Log.Warnf("扫描时%v", scanErr)
+		case errors.Is(scanErr, context.Canceled) && err != nil:
+			// 因替换出错而停止扫描，错误在下面统一处理
+		case summary != nil && summary.Files > 0:
+			// 已有文件被处理，照常写入报告，按部分失败退出
+			logger.Log.Errorf("扫描失败，已处理 %d 个文件: %v", summary.Files, scanErr)
+			scanFailed = true
+		default:
+			fatalf("扫描失败: %v", scanErr)
+		}
+	}
+
+	if summary != nil && summary.Interrupted {
+		logInterrupted(summary)
+	}
+
+	// 写入报告
+	if opts.report != nil && summary != nil {
+		if werr := report.New(cfg, summary).Write(opts.report); werr != nil {
+			logger.Log.Errorf("写入报告失败: %v", werr)
+		} else {
+			logger.Log.Infof("已写入报告 %s", opts.report.Path)
+		}
+	}
+
+	partial := scanFailed || len(fileScanner.Failures()) > 0 || (summary != nil && summary.Failed > 0)
+	switch {
+	case summary != nil && summary.Interrupted:
+		os.Exit(exitInterrupted)
+	case err != nil && !partial:
+		fatalf("替换失败: %v", err)
+	case partial:
+		if err != nil {
+			logger.Log.Errorf("替换失败: %v", err)
+		}
+		os.Exit(exitPartial)
+	case summary.Matched == 0:
+		logger.Log.Info("没有找到任何匹配")
+		os.Exit(exitNoMatch)
+	default:
+		os.Exit(exitChanged)
+	}
+}
+
+// 中断时在日志中列出的最多文件数，完整列表见运行报告
+const maxListedFiles = 20
+
+// logInterrupted 输出中断时的部分汇总：已修改的文件和已扫描但未处理的文件
+func logInterrupted(summary *replacer.Summary) {
+	var modified []string
+	for _, result := range summary.Results {
+		if result.ContentModified && result.Error == nil {
+			modified = append(modified, result.FilePath)
+		}
+	}
+	sort.Strings(modified)
+	unprocessed := append([]string(nil), summary.Unprocessed...)
+	sort.Strings(unprocessed)
+
+	logFileList("已处理且有替换的文件", modified)
+	// 扫描与替换同时进行，中断时尚未扫描到的文件不在此列
+	logFileList("已扫描但未处理的文件（不含尚未扫描到的文件）", unprocessed)
+}
+
+// logFileList 输出文件列表，超过 maxListedFiles 时省略其余部分
+func logFileList(title string, files []string) {
+	logger.Log.Warnf("%s (%d 个):", title, len(files))
+	for i, file := range files {
+		if i == maxListedFiles {
+			logger.Log.Warnf("  ... 其余 %d 个文件已省略，可使用 -report 查看完整列表", len(files)-maxListedFiles)
+			break
+		}
+		logger.Log.Warnf("  %s", file)
+	}
+}
+
+// parseConfig 解析替换和检查共用的命令行参数，生成配置
+// 参数有误时直接退出
+func parseConfig(fs *flag.FlagSet, args []string) (*config.Config, *options) {
 	cfg := config.NewDefaultConfig()
 
-	flag.StringVar(&cfg.RootDir, "dir", cfg.RootDir, "要扫描的根目录")
-	flag.StringVar(&cfg.SearchString, "search", "", "要查找的字符串 (单个替换时使用)")
-	flag.StringVar(&cfg.ReplaceString, "replace", "", "替换成的字符串 (单个替换时使用)")
-	flag.BoolVar(&cfg.Debug, "debug", false, "开启调试模式")
-	flag.BoolVar(&cfg.DryRun, "dry-run", false, "预览模式(不进行实际替换)")
-	flag.IntVar(&cfg.Threads, "threads", cfg.Threads, "并发线程数")
+	// 先加载配置文件，使文件中的值成为各参数的默认值，命令行参数再覆盖它们
+	if configPath := configPathFromArgs(args); configPath != "" {
+		if err := config.LoadFile(configPath, cfg); err != nil {
+			fatalf("加载配置文件失败: %v", err)
+		}
+	}
+
+	fs.String("config", "", "JSON 或 YAML 配置文件路径，命令行参数优先于文件中的值")
+	cfg.RootDirs = cfg.Roots()
+	fs.Var(newListFlag(&cfg.RootDirs, nil), "dir", "要扫描的根目录，可重复指定以扫描多个目录")
+	fs.StringVar(&cfg.SearchString, "search", "", "要查找的字符串 (单个替换时使用)")
+	fs.StringVar(&cfg.ReplaceString, "replace", "", "替换成的字符串 (单个替换时使用)")
+	fs.BoolVar(&cfg.Debug, "debug", cfg.Debug, "开启调试模式")
+	fs.BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "预览模式(不进行实际替换)")
+	fs.IntVar(&cfg.Threads, "threads", cfg.Threads, "并发线程数")
+	fs.StringVar(&cfg.OnError, "on-error", cfg.OnError, "出错处理策略: continue (记录并继续)、fail-fast (遇错即停) 或 collect (继续并汇总所有失败文件)")
+	fs.StringVar(&cfg.Mode, "mode", cfg.Mode, "替换方式: sequential (默认，按顺序依次替换) 或 simultaneous (所有替换项同时在原始内容上匹配，最长匹配优先)")
+	fs.BoolVar(&cfg.Strict, "strict", cfg.Strict, "替换项之间存在冲突或连锁替换时不执行替换 (默认只输出警告)")
+	fs.StringVar(&cfg.Engine, "engine", cfg.Engine, "替换引擎: buffered (默认) 或 unbuffered")
+	fs.BoolVar(&cfg.Binary, "binary", cfg.Binary, "同时处理二进制文件 (默认根据内容识别并跳过)")
+	fs.BoolVar(&cfg.Journal, "journal", cfg.Journal, "备份被修改文件的原始内容，以便用 undo 命令撤销")
+	fs.StringVar(&cfg.JournalDir, "journal-dir", cfg.JournalDir, "运行记录的存放目录")
+	fs.BoolVar(&cfg.KeepMtime, "keep-mtime", cfg.KeepMtime, "替换后保留文件原有的修改时间")
+	fs.BoolVar(&cfg.Diff, "diff", cfg.Diff, "预览模式下输出统一格式差异到标准输出")
+	fs.StringVar(&cfg.PatchFile, "patch", cfg.PatchFile, "预览模式下将差异写入指定的补丁文件 (可用 git apply 应用)")
+	fs.IntVar(&cfg.DiffContext, "diff-context", cfg.DiffContext, "差异的上下文行数")
 
-	ignoreFlag := flag.String("ignore", "", "要忽略的目录，用逗号分隔")
-	replacePairsFlag := flag.String("pairs", "", "替换对列表，格式: \"search1:replace1,search2:replace2\"")
-	pairsFileFlag := flag.String("pairs-file", "", "包含替换对的文件路径，每行一个替换对，格式: \"search replace\"")
+	fs.Var(newListFlag(&cfg.Include, splitPatternList), "include", "只处理匹配这些 glob 模式的文件 (相对根目录，如 \"**/*.{js,css,html,jsp}\")，可重复指定或用逗号分隔")
+	fs.Var(newListFlag(&cfg.Exclude, splitPatternList), "exclude", "排除匹配这些 glob 模式的文件和目录 (如 \"**/*.min.js\")，可重复指定或用逗号分隔")
+	fs.StringVar(&cfg.FilesFrom, "files-from", cfg.FilesFrom, "从文件读取要处理的文件列表 (每行一个路径或用 NUL 分隔)，为 \"-\" 时从标准输入读取，不再遍历根目录")
+	fs.BoolVar(&cfg.FilesFromFilter, "files-from-filter", cfg.FilesFromFilter, "对 -files-from 读取的文件同样应用 -ignore、-include、-exclude 等筛选规则")
+	fs.StringVar(&cfg.GitFiles, "git-files", cfg.GitFiles, "从 git 选择要处理的文件: tracked (已跟踪的文件)、changed (自 -since 以来改动的文件) 或 staged (暂存区中改动的文件)")
+	fs.StringVar(&cfg.GitSince, "since", cfg.GitSince, "-git-files changed 时比较的提交、分支或标签，单独指定时等同于 -git-files changed")
+	fs.BoolVar(&cfg.RespectGitignore, "respect-gitignore", cfg.RespectGitignore, "跳过被 .gitignore 和 .git/info/exclude 忽略的文件和目录")
 
-	flag.Parse()
+	ignoreFlag := fs.String("ignore", "", "要忽略的目录，用逗号分隔。目录名匹配任意层级的同名目录，含 / 的项为相对根目录的路径 (支持 glob)")
+	replacePairsFlag := fs.String("pairs", "", "替换对列表，格式: \"search1:replace1,search2:replace2\"")
+	reportFlag := fs.String("report", "", "运行结束后写入结构化报告，格式: \"json=路径\"")
+	pairsFileFlag := fs.String("pairs-file", "", "包含替换对的文件路径，每行一个替换对，格式: \"search replace\"、\"regex pattern replace\"、\"host 主机名 替换\" 或 \"identifier 标识符 替换\"")
+
+	fs.Parse(args)
 
 	// 处理忽略目录
 	if *ignoreFlag != "" {
@@ -37,6 +237,23 @@ func main() {
 			}
 		}
 	}
+	if err := cfg.NormalizeIgnoreDirs(); err != nil {
+		fatalf("%v", err)
+	}
+
+	// 处理根目录，嵌套在其他根目录中的目录只随外层目录扫描一次
+	nested, err := cfg.NormalizeRootDirs()
+	if err != nil {
+		fatalf("%v", err)
+	}
+	for _, dir := range nested {
+		logger.Log.Warnf("根目录 %s 位于其他根目录之下，不再单独扫描", dir)
+	}
+
+	// 只指定了比较的提交时，选择自该提交以来改动的文件
+	if cfg.GitSince != "" && cfg.GitFiles == "" {
+		cfg.GitFiles = config.GitChanged
+	}
 
 	// 处理替换对列表
 	if *replacePairsFlag != "" {
@@ -47,26 +264,45 @@ func main() {
 	if *pairsFileFlag != "" {
 		err := loadReplacePairsFromFile(cfg, *pairsFileFlag)
 		if err != nil {
-			logger.Log.Fatalf("加载替换对文件失败: %v", err)
+			fatalf("加载替换对文件失败: %v", err)
 		}
 	}
 
+	// 处理报告参数
+	opts := &options{}
+	if *reportFlag != "" {
+		target, err := report.ParseTarget(*reportFlag)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		opts.report = target
+	}
+
 	// 设置日志级别
 	logger.SetDebug(cfg.Debug)
 
-	// 执行扫描
-	fileScanner := scanner.NewFileScanner(cfg)
-	files, err := fileScanner.Scan()
-	if err != nil {
-		logger.Log.Fatalf("扫描失败: %v", err)
-	}
+	return cfg, opts
+}
 
-	// 执行替换
-	fileReplacer := replacer.NewReplacer(cfg)
-	err = fileReplacer.Replace(files)
-	if err != nil {
-		logger.Log.Fatalf("替换失败: %v", err)
+// configPathFromArgs 在解析参数前找出 -config 指定的配置文件路径
+func configPathFromArgs(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if strings.HasPrefix(name, "config=") {
+			return strings.TrimPrefix(name, "config=")
+		}
+		if name == "config" && i+1 < len(args) {
+			return args[i+1]
+		}
 	}
+	return ""
 }
 
 // 辅助函数: 分割逗号分隔列表
@@ -103,6 +339,19 @@ func loadReplacePairsFromFile(cfg *config.Config, filePath string) error {
 		}
 
 		parts := strings.Fields(line)
+		// 三列且首列为规则类型时，按 "类型 查找 替换" 解析
+		if len(parts) >= 3 && parts[0] == config.RuleRegex {
+			cfg.AddRegexItem(parts[1], parts[2])
+			continue
+		}
+		if len(parts) >= 3 && parts[0] == config.RuleHost {
+			cfg.AddHostItem(parts[1], parts[2])
+			continue
+		}
+		if len(parts) >= 3 && parts[0] == config.RuleIdentifier {
+			cfg.AddIdentifierItem(parts[1], parts[2])
+			continue
+		}
 		if len(parts) >= 2 {
 			search := parts[0]
 			replace := parts[1]
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/journal"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// runUndo 执行 undo 子命令: file-replacer undo [参数] <运行编号>
+func runUndo(args []string) {
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	journalDir := fs.String("journal-dir", config.DefaultJournalDir, "运行记录的存放目录")
+	force := fs.Bool("force", false, "即使文件在运行后被修改过也强制恢复")
+	list := fs.Bool("list", false, "列出所有可撤销的运行记录")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: file-replacer undo [参数] <运行编号>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *list {
+		runs, err := journal.List(*journalDir)
+		if err != nil {
+			fatalf("读取运行记录失败: %v", err)
+		}
+		if len(runs) == 0 {
+			fmt.Println("没有可撤销的运行记录")
+			return
+		}
+		for _, run := range runs {
+			fmt.Printf("%s\t%s\t%s\n", run.ID, run.Created.Format("2006-01-02 15:04:05"), strings.Join(run.Roots(), ","))
+		}
+		return
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	id := fs.Arg(0)
+	result, err := journal.Undo(*journalDir, id, *force)
+	if err != nil {
+		var conflict *journal.ConflictError
+		if errors.As(err, &conflict) {
+			logger.Log.Error(err)
+			fatalf("如需强制恢复，请加上 -force 参数")
+		}
+		fatalf("撤销运行 %s 失败: %v", id, err)
+	}
+
+	for _, path := range result.Restored {
+		logger.Log.Infof("已恢复文件 %s", path)
+	}
+	for _, path := range result.Unchanged {
+		logger.Log.Debugf("文件 %s 已是原始内容，跳过", path)
+	}
+	logger.Log.Infof("撤销完成，恢复 %d 个文件，跳过 %d 个未变化的文件",
+		len(result.Restored), len(result.Unchanged))
+}
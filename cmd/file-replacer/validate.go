@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/yourusername/file-replacer/internal/replacer"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// 校验命令的退出码，配置无效时使用 exitFatal
+const (
+	validateClean  = 0 // 没有发现问题
+	validateIssues = 1 // 替换项之间存在冲突或连锁替换
+)
+
+// runValidate 执行 validate 子命令: 不扫描文件，只检查配置和替换项，
+// 逐行输出 "类型: 说明"，发现问题时以非零状态退出
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	cfg, _ := parseConfig(fs, args)
+
+	// 标准输出只保留检查结果，日志改为输出到标准错误
+	logger.Log.SetOutput(os.Stderr)
+
+	if err := cfg.Validate(); err != nil {
+		fatalf("%v", err)
+	}
+	cfg.MergeLegacyItem()
+	issues, err := replacer.RuleIssues(cfg.ReplaceItems)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	for _, issue := range issues {
+		fmt.Printf("%s: %s\n", issue.Kind, issue.Message)
+	}
+
+	logger.Log.Infof("共 %d 个替换项，发现 %d 个问题", len(cfg.ReplaceItems), len(issues))
+	if len(issues) > 0 {
+		os.Exit(validateIssues)
+	}
+	os.Exit(validateClean)
+}
@@ -2,6 +2,10 @@ module github.com/yourusername/file-replacer
 
 go 1.20
 
-require github.com/sirupsen/logrus v1.9.3
+require (
+	github.com/bmatcuk/doublestar/v4 v4.9.1
+	github.com/sirupsen/logrus v1.9.3
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
@@ -1,42 +1,187 @@
 package config
 
-import "runtime"
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// 替换规则类型
+const (
+	// RuleLiteral 按字面字符串查找替换（默认）
+	RuleLiteral = "literal"
+	// RuleRegex 按正则表达式查找，替换串支持 $1、${name} 引用捕获组
+	RuleRegex = "regex"
+	// RuleIdentifier 按标识符重命名：查找和替换内容为词组（如 userAccount 或 "user account"），
+	// 同时匹配 camelCase、PascalCase、snake_case、SCREAMING_SNAKE、kebab-case 和 dot.case 写法，
+	// 每种写法替换为同样风格的替换内容
+	RuleIdentifier = "identifier"
+	// RuleHost 按主机名查找替换：不区分大小写，只匹配完整的主机名，
+	// 不会匹配 xqqt.cmicrwx.cn 或 qqt.cmicrwx.cn.evil.com
+	RuleHost = "host"
+)
 
 // ReplaceItem 表示一个替换项
 type ReplaceItem struct {
-	// 查找的字符串
-	SearchString string
+	// 查找的字符串（正则规则时为表达式）
+	SearchString string `json:"search" yaml:"search"`
 	// 替换的字符串
-	ReplaceString string
+	ReplaceString string `json:"replace" yaml:"replace"`
+	// 规则类型，为空时等同于 RuleLiteral
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// 字面规则同时匹配的编码写法（json、url、html、regex 或 all），匹配到哪种写法就用同样的写法替换
+	Variants []string `json:"variants,omitempty" yaml:"variants,omitempty"`
+	// 字面规则不区分大小写，替换内容沿用每处匹配的大小写形式（如 Foo→Bar、FOO→BAR、foo→bar）
+	IgnoreCase bool `json:"ignore_case,omitempty" yaml:"ignore_case,omitempty"`
+	// 只匹配完整的单词，如查找 id 时不会匹配 valid 中的 id，可用于任何类型的规则
+	WholeWord bool `json:"whole_word,omitempty" yaml:"whole_word,omitempty"`
+	// 判断单词边界的单词字符: identifier（默认）、hostname 或 "[...]" 形式的自定义字符类
+	TokenChars string `json:"token_chars,omitempty" yaml:"token_chars,omitempty"`
+
+	// 以下选项仅用于主机名规则
+	// 同时匹配点号被转义的写法（如正则中的 qqt\.cmicrwx\.cn），替换内容中的点号同样转义
+	EscapedDots bool `json:"escaped_dots,omitempty" yaml:"escaped_dots,omitempty"`
+	// 将匹配的主机名前的 http:// 或 https:// 改写为该协议，为空时不改写
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	// 将匹配的主机名后的端口号改写为该端口，为空时不改写
+	Port string `json:"port,omitempty" yaml:"port,omitempty"`
+}
+
+// 字面规则可额外匹配的编码写法
+const (
+	// VariantJSON JSON 字符串中的写法，如 qqt.cmicrwx.cn\/path
+	VariantJSON = "json"
+	// VariantURL 百分号编码的写法，如 https%3A%2F%2Fqqt.cmicrwx.cn
+	VariantURL = "url"
+	// VariantHTML HTML 实体编码的写法，如 &amp;
+	VariantHTML = "html"
+	// VariantRegex 正则表达式中转义后的写法，如 qqt\.cmicrwx\.cn
+	VariantRegex = "regex"
+	// VariantAll 以上所有写法
+	VariantAll = "all"
+)
+
+// 判断单词边界时使用的单词字符
+const (
+	// TokenIdentifier 字母、数字和下划线（默认）
+	TokenIdentifier = "identifier"
+	// TokenHostname 字母、数字、下划线、连字符和点号
+	TokenHostname = "hostname"
+)
+
+// IsRegex 判断是否为正则规则
+func (item ReplaceItem) IsRegex() bool {
+	return item.Type == RuleRegex
+}
+
+// IsHost 判断是否为主机名规则
+func (item ReplaceItem) IsHost() bool {
+	return item.Type == RuleHost
 }
 
+// 出错处理策略
+const (
+	// OnErrorContinue 记录警告并继续处理其他文件（默认）
+	OnErrorContinue = "continue"
+	// OnErrorFailFast 遇到第一个错误即停止，不再处理剩余文件
+	OnErrorFailFast = "fail-fast"
+	// OnErrorCollect 继续处理其他文件，结束时返回列出所有失败文件的汇总错误
+	OnErrorCollect = "collect"
+)
+
+// 替换方式
+const (
+	// ModeSequential 按顺序依次应用替换项，后面的替换项在前面替换后的内容上查找（默认）
+	ModeSequential = "sequential"
+	// ModeSimultaneous 所有替换项同时在原始内容上查找，重叠时起始位置靠前、更长的匹配优先，
+	// 起始位置和长度都相同时排在前面的替换项优先
+	ModeSimultaneous = "simultaneous"
+)
+
+// git 文件选择方式：不遍历目录，而是从 git 读取要处理的文件列表
+const (
+	// GitTracked 只处理 git 跟踪的文件
+	GitTracked = "tracked"
+	// GitChanged 只处理当前分支自 GitSince 指定的提交以来改动过的文件（包括尚未提交的改动）
+	GitChanged = "changed"
+	// GitStaged 只处理暂存区中改动的文件
+	GitStaged = "staged"
+)
+
+// DefaultJournalDir 默认的运行记录目录
+const DefaultJournalDir = ".file-replacer"
+
 // Config 应用程序配置
 type Config struct {
 	// 要扫描的根目录
-	RootDir string
-	// 要忽略的目录列表
-	IgnoreDirs []string
+	RootDir string `json:"root_dir" yaml:"root_dir"`
+	// 要扫描的多个根目录，不为空时取代 RootDir
+	RootDirs []string `json:"root_dirs" yaml:"root_dirs"`
+	// 要忽略的目录列表：不含 / 的项为目录名，匹配任意层级的同名目录；
+	// 含 / 的项为相对根目录的路径（支持 glob 模式），如 res/wap/activityPages
+	IgnoreDirs []string `json:"ignore_dirs" yaml:"ignore_dirs"`
+	// 只处理匹配这些 glob 模式的文件（相对根目录，支持 ** 和 {a,b}），为空时处理所有文件
+	Include []string `json:"include" yaml:"include"`
+	// 排除匹配这些 glob 模式的文件和目录
+	Exclude []string `json:"exclude" yaml:"exclude"`
+	// 是否跳过被 .gitignore 和 .git/info/exclude 忽略的文件和目录
+	RespectGitignore bool `json:"respect_gitignore" yaml:"respect_gitignore"`
+	// git 文件选择方式: tracked、changed 或 staged，为空时遍历根目录
+	GitFiles string `json:"git_files" yaml:"git_files"`
+	// GitFiles 为 changed 时比较的提交、分支或标签，如 origin/main
+	GitSince string `json:"git_since" yaml:"git_since"`
+	// 从该文件读取要处理的文件列表（每行一个路径，或用 NUL 分隔），为 "-" 时从标准输入读取，
+	// 为空时遍历根目录
+	FilesFrom string `json:"files_from" yaml:"files_from"`
+	// 对 FilesFrom 读取的文件同样应用忽略目录、include/exclude 和忽略文件等筛选规则
+	FilesFromFilter bool `json:"files_from_filter" yaml:"files_from_filter"`
 	// 替换项列表
-	ReplaceItems []ReplaceItem
+	ReplaceItems []ReplaceItem `json:"rules" yaml:"rules"`
 	// 是否启用调试模式
-	Debug bool
+	Debug bool `json:"debug" yaml:"debug"`
 	// 是否进行实际替换（false为仅预览）
-	DryRun bool
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
 	// 并发线程数
-	Threads int
+	Threads int `json:"threads" yaml:"threads"`
+	// 出错处理策略: continue（默认）、fail-fast 或 collect，扫描和替换共用
+	OnError string `json:"on_error" yaml:"on_error"`
+	// 替换方式: sequential（默认）或 simultaneous
+	Mode string `json:"mode" yaml:"mode"`
+	// 替换项之间存在冲突或连锁替换时是否停止运行（默认只输出警告）
+	Strict bool `json:"strict" yaml:"strict"`
+	// 替换引擎: buffered（默认）或 unbuffered
+	Engine string `json:"engine" yaml:"engine"`
+	// 是否同时处理二进制文件（默认跳过）
+	Binary bool `json:"binary" yaml:"binary"`
+	// 是否记录被修改文件的原始内容以便撤销
+	Journal bool `json:"journal" yaml:"journal"`
+	// 运行记录的存放目录
+	JournalDir string `json:"journal_dir" yaml:"journal_dir"`
+	// 写入时是否保留文件原有的修改时间
+	KeepMtime bool `json:"keep_mtime" yaml:"keep_mtime"`
+	// 预览模式下是否输出统一格式差异
+	Diff bool `json:"diff" yaml:"diff"`
+	// 预览模式下差异写入的补丁文件，为空时输出到标准输出
+	PatchFile string `json:"patch_file" yaml:"patch_file"`
+	// 差异的上下文行数
+	DiffContext int `json:"diff_context" yaml:"diff_context"`
 	// 兼容旧版的单个替换项
-	SearchString  string
-	ReplaceString string
+	SearchString  string `json:"-" yaml:"-"`
+	ReplaceString string `json:"-" yaml:"-"`
 }
 
 // NewDefaultConfig 返回默认配置
 // "D:\\project\\cx_project\\china_mobile\\gitProject\\qqtgy\\src\\main\\webapp\\res\\wap"
-// D:\project\cx_project\china_mobile\gitProject\bigclass\src\main\webapp\res\wap =》 \\
-//  D:\\project\\cx_project\\china_mobile\\gitProject\\bigclass\\src\\main\\webapp\\res\\wap
+//
+//	D:\project\cx_project\china_mobile\gitProject\bigclass\src\main\webapp\res\wap =》 \\
+//	 D:\\project\\cx_project\\china_mobile\\gitProject\\bigclass\\src\\main\\webapp\\res\\wap
 func NewDefaultConfig() *Config {
 	return &Config{
 		RootDir:    "D:\\project\\cx_project\\china_mobile\\gitProject\\bigclass\\src\\main\\webapp\\res\\wap",
-		IgnoreDirs: []string{"activityPages,.git", "node_modules", "vendor", "build", "dist"},
+		IgnoreDirs: []string{"activityPages", ".git", "node_modules", "vendor", "build", "dist", DefaultJournalDir},
 		ReplaceItems: []ReplaceItem{
 			{
 				SearchString:  "qqt.cmicrwx.cn",
@@ -47,12 +192,200 @@ func NewDefaultConfig() *Config {
 				ReplaceString: "qqt.cmicvip.cn",
 			},
 		},
-		Debug:   false,
-		DryRun:  false,
-		Threads: runtime.NumCPU(), // 使用CPU核心数作为默认线程数
+		Debug:       false,
+		DryRun:      false,
+		Threads:     runtime.NumCPU(), // 使用CPU核心数作为默认线程数
+		DiffContext: 3,
+		OnError:     OnErrorContinue,
+		Mode:        ModeSequential,
+		Journal:     true,
+		JournalDir:  DefaultJournalDir,
 	}
 }
 
+// Validate 检查配置中各选项的取值，各命令在解析参数后调用一次
+func (c *Config) Validate() error {
+	checks := []func() error{c.validateOnError, c.validateThreads, c.validateMode, c.validateGitFiles, c.validateFilesFrom}
+	for _, check := range checks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOnError 检查出错处理策略是否有效
+func (c *Config) validateOnError() error {
+	switch c.OnError {
+	case "", OnErrorContinue, OnErrorFailFast, OnErrorCollect:
+		return nil
+	}
+	return fmt.Errorf("未知的出错处理策略: %s（可选 continue、fail-fast、collect）", c.OnError)
+}
+
+// validateMode 检查替换方式是否有效
+func (c *Config) validateMode() error {
+	switch c.Mode {
+	case "", ModeSequential, ModeSimultaneous:
+		return nil
+	}
+	return fmt.Errorf("未知的替换方式: %s（可选 sequential、simultaneous）", c.Mode)
+}
+
+// validateThreads 检查并发线程数是否有效
+func (c *Config) validateThreads() error {
+	if c.Threads < 1 {
+		return fmt.Errorf("并发线程数必须大于 0: %d", c.Threads)
+	}
+	return nil
+}
+
+// Roots 返回要扫描的根目录：设置了 RootDirs 时为 RootDirs，否则为 RootDir
+func (c *Config) Roots() []string {
+	if len(c.RootDirs) > 0 {
+		return c.RootDirs
+	}
+	if c.RootDir == "" {
+		return nil
+	}
+	return []string{c.RootDir}
+}
+
+// NormalizeRootDirs 整理要扫描的根目录：规范化路径，去掉重复的目录，以及位于其他根目录之下的目录（它们的文件会随外层目录一起扫描），
+// 结果保存到 RootDirs，RootDir 设为第一个根目录。返回被去掉的嵌套目录
+func (c *Config) NormalizeRootDirs() ([]string, error) {
+	roots := c.Roots()
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("没有指定要扫描的根目录")
+	}
+
+	roots = append([]string{}, roots...)
+	abs := make([]string, len(roots))
+	for i, root := range roots {
+		root = filepath.Clean(root)
+		roots[i] = root
+		a, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("根目录 %s 无效: %v", root, err)
+		}
+		abs[i] = a
+	}
+
+	var kept, nested []string
+	seen := make(map[string]bool)
+	for i, root := range roots {
+		if seen[abs[i]] {
+			continue
+		}
+		seen[abs[i]] = true
+		inside := false
+		for j := range roots {
+			if abs[j] != abs[i] && isWithin(abs[i], abs[j]) {
+				inside = true
+				break
+			}
+		}
+		if inside {
+			nested = append(nested, root)
+			continue
+		}
+		kept = append(kept, root)
+	}
+
+	c.RootDirs = kept
+	c.RootDir = kept[0]
+	return nested, nil
+}
+
+// isWithin 判断绝对路径 path 是否位于目录 dir 之下
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return rel != "."
+}
+
+// validateGitFiles 检查 git 文件选择方式是否有效，changed 方式必须指定比较的提交
+func (c *Config) validateGitFiles() error {
+	switch c.GitFiles {
+	case "", GitTracked, GitStaged:
+		if c.GitSince != "" {
+			return fmt.Errorf("git_since 只能与 git_files: changed 一起使用")
+		}
+		return nil
+	case GitChanged:
+		if c.GitSince == "" {
+			return fmt.Errorf("git_files 为 changed 时需要用 git_since 指定比较的提交")
+		}
+		// 以 - 开头的值会被 git 当作选项
+		if strings.HasPrefix(c.GitSince, "-") {
+			return fmt.Errorf("git_since '%s' 无效：提交、分支或标签不能以 - 开头", c.GitSince)
+		}
+		return nil
+	}
+	return fmt.Errorf("未知的 git 文件选择方式: %s（可选 tracked、changed、staged）", c.GitFiles)
+}
+
+// validateFilesFrom 检查文件列表的设置：不能与 git 文件选择方式同时使用，
+// files_from_filter 只能与 files_from 一起使用
+func (c *Config) validateFilesFrom() error {
+	if c.FilesFrom != "" && c.GitFiles != "" {
+		return fmt.Errorf("files_from 不能与 git_files 同时使用")
+	}
+	if c.FilesFromFilter && c.FilesFrom == "" {
+		return fmt.Errorf("files_from_filter 只能与 files_from 一起使用")
+	}
+	return nil
+}
+
+// NormalizeIgnoreDirs 整理并校验忽略目录列表：拆分用逗号连接的项，去掉空白、空项和重复项，
+// 路径统一使用 /，并去掉开头的 ./ 或 / 以及结尾的 /。路径含有 . 或 .. 或不是有效的 glob 模式时返回错误
+func (c *Config) NormalizeIgnoreDirs() error {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, entry := range c.IgnoreDirs {
+		for _, dir := range strings.Split(entry, ",") {
+			dir = strings.TrimSpace(filepath.ToSlash(dir))
+			if dir == "" {
+				continue
+			}
+			dir = strings.Trim(strings.TrimPrefix(dir, "./"), "/")
+			if dir == "" {
+				return fmt.Errorf("忽略目录 '%s' 无效: 不能忽略根目录", entry)
+			}
+			for _, part := range strings.Split(dir, "/") {
+				if part == "" || part == "." || part == ".." {
+					return fmt.Errorf("忽略目录 '%s' 无效: 路径应相对根目录，且不能包含空的、. 或 .. 的路径段", entry)
+				}
+			}
+			if !doublestar.ValidatePattern(dir) {
+				return fmt.Errorf("忽略目录 '%s' 不是有效的匹配模式", entry)
+			}
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	c.IgnoreDirs = dirs
+	return nil
+}
+
+// MergeLegacyItem 将旧版的单个替换项（-search/-replace）添加到替换项列表中，已存在相同的替换项时不重复添加
+func (c *Config) MergeLegacyItem() {
+	if c.SearchString == "" || c.ReplaceString == "" {
+		return
+	}
+	for _, item := range c.ReplaceItems {
+		if item.SearchString == c.SearchString && item.ReplaceString == c.ReplaceString {
+			return
+		}
+	}
+	c.AddReplaceItem(c.SearchString, c.ReplaceString)
+}
+
 // AddReplaceItem 添加一个替换项
 func (c *Config) AddReplaceItem(search, replace string) {
 	c.ReplaceItems = append(c.ReplaceItems, ReplaceItem{
@@ -60,3 +393,30 @@ func (c *Config) AddReplaceItem(search, replace string) {
 		ReplaceString: replace,
 	})
 }
+
+// AddHostItem 添加一个主机名替换项
+func (c *Config) AddHostItem(host, replace string) {
+	c.ReplaceItems = append(c.ReplaceItems, ReplaceItem{
+		SearchString:  host,
+		ReplaceString: replace,
+		Type:          RuleHost,
+	})
+}
+
+// AddIdentifierItem 添加一个标识符替换项
+func (c *Config) AddIdentifierItem(phrase, replace string) {
+	c.ReplaceItems = append(c.ReplaceItems, ReplaceItem{
+		SearchString:  phrase,
+		ReplaceString: replace,
+		Type:          RuleIdentifier,
+	})
+}
+
+// AddRegexItem 添加一个正则替换项
+func (c *Config) AddRegexItem(pattern, replace string) {
+	c.ReplaceItems = append(c.ReplaceItems, ReplaceItem{
+		SearchString:  pattern,
+		ReplaceString: replace,
+		Type:          RuleRegex,
+	})
+}
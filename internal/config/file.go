@@ -0,0 +1,43 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile 从 JSON 或 YAML 配置文件加载配置
+// 文件中出现的字段会覆盖 cfg 中的现有值，未出现的字段保持不变
+func LoadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("解析 JSON 配置文件 %s 失败: %v", path, err)
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		// 空文件视为没有任何配置
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("解析 YAML 配置文件 %s 失败: %v", path, err)
+		}
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s（仅支持 .json、.yaml、.yml）", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,371 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 编辑操作类型
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit 表示一行的编辑操作
+type edit struct {
+	kind opKind
+	// 行在旧内容中的下标（插入时为 -1）
+	oldIndex int
+	// 行在新内容中的下标（删除时为 -1）
+	newIndex int
+}
+
+// Unified 生成 oldName 到 newName 的统一格式差异（git apply 可直接应用）
+// context 为每个差异块前后保留的上下文行数，内容相同时返回空字符串
+func Unified(oldName, newName, oldContent, newContent string, context int) string {
+	if oldContent == newContent {
+		return ""
+	}
+	if context < 0 {
+		context = 0
+	}
+
+	a := splitLines(oldContent)
+	b := splitLines(newContent)
+	edits := lineEdits(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", oldName, newName)
+	fmt.Fprintf(&sb, "--- a/%s\n", oldName)
+	fmt.Fprintf(&sb, "+++ b/%s\n", newName)
+
+	// 已经过的编辑数，以及其中的旧行数和新行数
+	done, oldBefore, newBefore := 0, 0, 0
+	for _, h := range buildHunks(edits, context) {
+		for _, e := range edits[done:h.start] {
+			if e.kind != opInsert {
+				oldBefore++
+			}
+			if e.kind != opDelete {
+				newBefore++
+			}
+		}
+		done = h.start
+		writeHunk(&sb, h, edits, a, b, oldBefore, newBefore)
+	}
+	return sb.String()
+}
+
+// splitLines 按行切分内容，每行保留行尾的换行符
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineEdits 计算两组行之间的编辑序列。去掉公共前缀和后缀后，两侧剩余行数相同时逐行对应
+// （替换通常不改变行数，改动分散在大文件各处时也只需线性的时间和内存），否则使用线性空间的 Myers 算法
+func lineEdits(a, b []string) []edit {
+	// 去掉公共前缀和后缀，通常替换只涉及少量行
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, edit{kind: opEqual, oldIndex: i, newIndex: i})
+	}
+	if len(a) == len(b) {
+		edits = pairLines(edits, a, b, prefix, len(a)-suffix)
+	} else {
+		d := newDiffer(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix)
+		d.compare(0, len(d.a), 0, len(d.b))
+		edits = append(edits, d.edits...)
+	}
+	for i := 0; i < suffix; i++ {
+		oi := len(a) - suffix + i
+		ni := len(b) - suffix + i
+		edits = append(edits, edit{kind: opEqual, oldIndex: oi, newIndex: ni})
+	}
+	return edits
+}
+
+// pairLines 逐行对应 a[lo:hi] 和 b[lo:hi]，连续的不同行先输出删除再输出插入
+func pairLines(edits []edit, a, b []string, lo, hi int) []edit {
+	for i := lo; i < hi; {
+		if a[i] == b[i] {
+			edits = append(edits, edit{kind: opEqual, oldIndex: i, newIndex: i})
+			i++
+			continue
+		}
+		j := i
+		for j < hi && a[j] != b[j] {
+			j++
+		}
+		for k := i; k < j; k++ {
+			edits = append(edits, edit{kind: opDelete, oldIndex: k, newIndex: -1})
+		}
+		for k := i; k < j; k++ {
+			edits = append(edits, edit{kind: opInsert, oldIndex: -1, newIndex: k})
+		}
+		i = j
+	}
+	return edits
+}
+
+// maxCost 查找中间蛇形的最大步数，超过时得到的编辑序列可能不是最短的
+const maxCost = 256
+
+// differ 使用 Myers 的线性空间算法（查找中间蛇形后分治）计算最短编辑序列
+type differ struct {
+	// 行替换为编号后的内容，相同的行编号相同
+	a, b []int
+	// a、b 在原始内容中的起始行号
+	offset int
+	// 正向和反向搜索时各对角线能到达的最远位置，按对角线加 len(a)+len(b)+1 存放
+	forward, backward []int
+	edits             []edit
+}
+
+func newDiffer(a, b []string, offset int) *differ {
+	ids := make(map[string]int, len(a)+len(b))
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	size := 2*(len(a)+len(b)) + 3
+	return &differ{
+		a:        intern(a),
+		b:        intern(b),
+		offset:   offset,
+		forward:  make([]int, size),
+		backward: make([]int, size),
+	}
+}
+
+// compare 追加 a[aLo:aHi] 到 b[bLo:bHi] 的最短编辑序列
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.equal(aLo, bLo)
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aHi-suffix > aLo && bHi-suffix > bLo && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.edits = append(d.edits, edit{kind: opInsert, oldIndex: -1, newIndex: d.offset + y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.edits = append(d.edits, edit{kind: opDelete, oldIndex: d.offset + x, newIndex: -1})
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.equal(x, y)
+		}
+		d.compare(u, aHi, v, bHi)
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.equal(aHi+i, bHi+i)
+	}
+}
+
+func (d *differ) equal(x, y int) {
+	d.edits = append(d.edits, edit{kind: opEqual, oldIndex: d.offset + x, newIndex: d.offset + y})
+}
+
+// middleSnake 从两端同时搜索，返回最短编辑路径中间的一段对角线 (x, y) 到 (u, v)。
+// 调用前两端已去掉公共行，且两侧都不为空
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	// 对角线 k 的下标为 mid+k，k±1 不会越界
+	mid := n + m + 1
+	vf, vb := d.forward, d.backward
+	vf[mid+1], vb[mid+1] = 0, 0
+
+	for step := 0; step <= (n+m+1)/2; step++ {
+		// 正向：k = x - y，位置相对 (aLo, bLo)
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || k != step && vf[mid+k-1] < vf[mid+k+1] {
+				x = vf[mid+k+1]
+			} else {
+				x = vf[mid+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[mid+k] = x
+			// 反向搜索在对角线 delta-k 上已走过 step-1 步
+			if kr := delta - k; odd && kr >= -(step-1) && kr <= step-1 && x+vb[mid+kr] >= n {
+				return aLo + x0, bLo + y0, aLo + x, bLo + y
+			}
+		}
+		// 反向：从 (aHi, bHi) 往回走，xr、yr 为距终点的行数，k = xr - yr
+		for k := -step; k <= step; k += 2 {
+			var xr int
+			if k == -step || k != step && vb[mid+k-1] < vb[mid+k+1] {
+				xr = vb[mid+k+1]
+			} else {
+				xr = vb[mid+k-1] + 1
+			}
+			yr := xr - k
+			xr0, yr0 := xr, yr
+			for xr < n && yr < m && d.a[aHi-xr-1] == d.b[bHi-yr-1] {
+				xr++
+				yr++
+			}
+			vb[mid+k] = xr
+			if kf := delta - k; !odd && kf >= -step && kf <= step && xr+vf[mid+kf] >= n {
+				return aHi - xr, bHi - yr, aHi - xr0, bHi - yr0
+			}
+		}
+
+		// 改动很多时不再查找最短路径，从两端走得最远的位置拆开，保证时间大致与行数成正比
+		if step >= maxCost {
+			if x, y, ok := furthest(vf, mid, step, n, m); ok {
+				return aLo + x, bLo + y, aLo + x, bLo + y
+			}
+			if xr, yr, ok := furthest(vb, mid, step, n, m); ok {
+				return aHi - xr, bHi - yr, aHi - xr, bHi - yr
+			}
+		}
+	}
+	// 两端的搜索最多各走一半的步数就会相遇
+	panic("diff: middle snake not found")
+}
+
+// furthest 返回一个方向上第 step 步走得最远、且能把问题拆为两个更小部分的位置（相对该方向的起点）
+func furthest(v []int, mid, step, n, m int) (x, y int, ok bool) {
+	best := -1
+	for k := -step; k <= step; k += 2 {
+		px := v[mid+k]
+		py := px - k
+		if px > n || py > m || py < 0 || px+py >= n+m {
+			continue
+		}
+		if px+py > best {
+			best, x, y = px+py, px, py
+		}
+	}
+	return x, y, best > 0
+}
+
+// hunk 表示一个差异块，对应 edits[start:end]
+type hunk struct {
+	start int
+	end   int
+}
+
+// buildHunks 将编辑序列按上下文行数划分为差异块，间隔不超过 2*context 行的改动合并为一块
+func buildHunks(edits []edit, context int) []hunk {
+	var hunks []hunk
+	for i := 0; i < len(edits); i++ {
+		if edits[i].kind == opEqual {
+			continue
+		}
+
+		// 找到这段连续改动的结尾
+		j := i
+		for j < len(edits) && edits[j].kind != opEqual {
+			j++
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := j + context
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start: start, end: end})
+		}
+		i = j - 1
+	}
+	return hunks
+}
+
+// writeHunk 输出一个差异块，oldBefore 和 newBefore 为块之前的旧行数和新行数
+func writeHunk(sb *strings.Builder, h hunk, edits []edit, a, b []string, oldBefore, newBefore int) {
+	oldCount, newCount := 0, 0
+	for _, e := range edits[h.start:h.end] {
+		if e.kind != opInsert {
+			oldCount++
+		}
+		if e.kind != opDelete {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n",
+		rangeStart(oldBefore, oldCount), oldCount, rangeStart(newBefore, newCount), newCount)
+	for _, e := range edits[h.start:h.end] {
+		switch e.kind {
+		case opEqual:
+			writeLine(sb, ' ', a[e.oldIndex])
+		case opDelete:
+			writeLine(sb, '-', a[e.oldIndex])
+		case opInsert:
+			writeLine(sb, '+', b[e.newIndex])
+		}
+	}
+}
+
+// rangeStart 计算差异块头部的起始行号（从 1 开始），范围为空时取其前一行
+func rangeStart(before, count int) int {
+	if count == 0 {
+		return before
+	}
+	return before + 1
+}
+
+// writeLine 输出带前缀的一行，缺少行尾换行时追加 git 使用的提示行
+func writeLine(sb *strings.Builder, prefix byte, line string) {
+	sb.WriteByte(prefix)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
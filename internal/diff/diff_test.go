@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// applyPatch 将 Unified 生成的差异应用到旧内容上，检查上下文和删除的行与旧内容一致
+func applyPatch(old, patch string) (string, error) {
+	a := splitLines(old)
+	lines := strings.SplitAfter(patch, "\n")
+	if len(lines) < 3 {
+		return "", fmt.Errorf("缺少差异头部")
+	}
+
+	var out strings.Builder
+	pos := 0
+	for i := 3; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "@@ -") {
+			return "", fmt.Errorf("第 %d 行不是差异块头部: %q", i+1, line)
+		}
+		// @@ -start,count +start,count @@
+		oldRange := strings.Fields(line)[1][1:]
+		parts := strings.Split(oldRange, ",")
+		start, _ := strconv.Atoi(parts[0])
+		count, _ := strconv.Atoi(parts[1])
+		if count > 0 {
+			start--
+		}
+		if start < pos || start > len(a) {
+			return "", fmt.Errorf("差异块起始行 %d 无效", start+1)
+		}
+		for ; pos < start; pos++ {
+			out.WriteString(a[pos])
+		}
+
+		for i+1 < len(lines) && lines[i+1] != "" && !strings.HasPrefix(lines[i+1], "@@") {
+			i++
+			prefix, text := lines[i][0], lines[i][1:]
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\ `) {
+				text = strings.TrimSuffix(text, "\n")
+				i++
+			}
+			switch prefix {
+			case ' ', '-':
+				if pos >= len(a) || a[pos] != text {
+					return "", fmt.Errorf("旧内容第 %d 行与差异不一致", pos+1)
+				}
+				pos++
+				if prefix == ' ' {
+					out.WriteString(text)
+				}
+			case '+':
+				out.WriteString(text)
+			default:
+				return "", fmt.Errorf("无效的差异行: %q", lines[i])
+			}
+		}
+	}
+	for ; pos < len(a); pos++ {
+		out.WriteString(a[pos])
+	}
+	return out.String(), nil
+}
+
+// randomLines 生成由少量不同行组成的内容，末尾可能没有换行
+func randomLines(rng *rand.Rand, max int) string {
+	var b strings.Builder
+	n := rng.Intn(max + 1)
+	for i := 0; i < n; i++ {
+		b.WriteString(string(rune('a' + rng.Intn(4))))
+		if i < n-1 || rng.Intn(4) > 0 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// lcs 用动态规划计算最长公共子序列的长度
+func lcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				dp[i][j] = dp[i+1][j+1] + 1
+			case dp[i+1][j] > dp[i][j+1]:
+				dp[i][j] = dp[i+1][j]
+			default:
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+// TestUnifiedApplies 随机生成新旧内容，检查差异应用到旧内容后得到新内容，
+// 行数不同时编辑序列是最短的
+func TestUnifiedApplies(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 20000; n++ {
+		oldContent := randomLines(rng, 12)
+		newContent := randomLines(rng, 12)
+		context := rng.Intn(4)
+
+		patch := Unified("f", "f", oldContent, newContent, context)
+		if oldContent == newContent {
+			if patch != "" {
+				t.Fatalf("内容相同时应没有差异，得到 %q", patch)
+			}
+			continue
+		}
+		got, err := applyPatch(oldContent, patch)
+		if err != nil {
+			t.Fatalf("%q -> %q 的差异无法应用: %v\n%s", oldContent, newContent, err, patch)
+		}
+		if got != newContent {
+			t.Fatalf("%q -> %q 的差异应用后得到 %q\n%s", oldContent, newContent, got, patch)
+		}
+
+		a, b := splitLines(oldContent), splitLines(newContent)
+		if len(a) == len(b) {
+			continue
+		}
+		changes := 0
+		for _, e := range lineEdits(a, b) {
+			if e.kind != opEqual {
+				changes++
+			}
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); changes != want {
+			t.Fatalf("%q -> %q 有 %d 行改动，最少为 %d 行", oldContent, newContent, changes, want)
+		}
+	}
+}
+
+// hostLines 生成 n 行 JSON，每隔 every 行的链接使用新的主机名
+func hostLines(n, every int, host string) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		h := "static.example.com"
+		if i%every == 0 {
+			h = host
+		}
+		lines[i] = fmt.Sprintf("  {\"id\": %d, \"url\": \"https://%s/app/%d.js\"},\n", i, h, i)
+	}
+	return lines
+}
+
+// TestUnifiedLargeScatteredChange 大文件中分散的大量改动：行数不变和行数改变时都能在短时间内生成
+// 可以应用的差异
+func TestUnifiedLargeScatteredChange(t *testing.T) {
+	lines := 30000
+	if testing.Short() {
+		lines = 5000
+	}
+	oldLines := hostLines(lines, 1, "qqt.cmicrwx.cn")
+	newLines := hostLines(lines, 2, "qqt.cmicvip.cn")
+	for i := range newLines {
+		if i%2 != 0 {
+			newLines[i] = oldLines[i]
+		}
+	}
+	oldContent := strings.Join(oldLines, "")
+
+	tests := map[string]string{
+		"行数不变": strings.Join(newLines, ""),
+		// 中间插入一行，两侧行数不同
+		"行数改变": strings.Join(newLines[:lines/2], "") + "  {},\n" + strings.Join(newLines[lines/2:], ""),
+	}
+	for name, newContent := range tests {
+		t.Run(name, func(t *testing.T) {
+			patch := Unified("data.json", "data.json", oldContent, newContent, 3)
+			got, err := applyPatch(oldContent, patch)
+			if err != nil {
+				t.Fatalf("差异无法应用: %v", err)
+			}
+			if got != newContent {
+				t.Fatal("差异应用后与新内容不一致")
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package failures
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Failure 单个路径的错误
+type Failure struct {
+	Path string
+	Err  error
+}
+
+// Error 多个路径错误的汇总
+type Error struct {
+	Failures []Failure
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共 %d 个文件处理失败:", len(e.Failures))
+	for _, f := range e.Failures {
+		fmt.Fprintf(&sb, "\n  %s: %v", f.Path, f.Err)
+	}
+	return sb.String()
+}
+
+// List 可并发记录的错误列表
+type List struct {
+	mu       sync.Mutex
+	failures []Failure
+}
+
+// Add 记录一个路径的错误
+func (l *List) Add(path string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failures = append(l.failures, Failure{Path: path, Err: err})
+}
+
+// Len 返回已记录的错误数
+func (l *List) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.failures)
+}
+
+// First 返回最早记录的错误，没有错误时返回 nil
+func (l *List) First() *Failure {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.failures) == 0 {
+		return nil
+	}
+	first := l.failures[0]
+	return &first
+}
+
+// Failures 返回按路径排序的错误列表
+func (l *List) Failures() []Failure {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	result := append([]Failure(nil), l.failures...)
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// Err 没有错误时返回 nil，否则返回汇总错误
+func (l *List) Err() error {
+	failures := l.Failures()
+	if len(failures) == 0 {
+		return nil
+	}
+	return &Error{Failures: failures}
+}
@@ -0,0 +1,117 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// preservedMode 重命名覆盖时保留的权限位，包括 setuid、setgid 和 sticky
+const preservedMode = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// WriteFileAtomic 以原子方式覆盖已有文件
+// 内容先写入同目录下的临时文件并同步到磁盘，再重命名覆盖原文件，
+// 写入过程中崩溃不会留下被截断的文件。原文件的权限（包括 setuid、setgid 和 sticky 位）会被保留，
+// 并尽量保留属主和属组；keepMtime 为 true 时同时保留原文件的修改时间。
+// 文件有多个硬链接时，重命名会使其他链接仍指向旧内容，因此改为直接覆盖原文件，此时写入不是原子的
+func WriteFileAtomic(path string, data []byte, keepMtime bool) error {
+	// 符号链接写入其指向的目标文件，避免把链接替换成普通文件
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	uid, gid, nlink, owned := fileOwner(info)
+	if owned && nlink > 1 {
+		logger.Log.Warnf("文件 %s 有 %d 个硬链接，将直接覆盖原文件以保持链接一致，写入过程不是原子的", path, nlink)
+		return overwriteFile(target, info, data, keepMtime)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	// 出错时清理临时文件
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	// 先修改属主再设置权限，修改属主会清除 setuid 和 setgid 位
+	if owned && (uid != os.Getuid() || gid != os.Getgid()) {
+		if err := tmp.Chown(uid, gid); err != nil {
+			logger.Log.Warnf("无法保留文件 %s 的属主 %d:%d，写入后属主将变为当前用户: %v", path, uid, gid, err)
+		}
+	}
+	if err := tmp.Chmod(info.Mode() & preservedMode); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if keepMtime {
+		if err := os.Chtimes(tmpName, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpName, target); err != nil {
+		return err
+	}
+	committed = true
+
+	syncDir(filepath.Dir(target))
+	return nil
+}
+
+// overwriteFile 截断原文件后直接写入新内容，保留文件的 inode、属主、权限和硬链接
+func overwriteFile(path string, info os.FileInfo, data []byte, keepMtime bool) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if keepMtime {
+		return os.Chtimes(path, info.ModTime(), info.ModTime())
+	}
+	return nil
+}
+
+// syncDir 尽力将目录项的变更同步到磁盘，部分平台不支持时忽略错误
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package fsutil
+
+import "os"
+
+// fileOwner 该平台不支持获取文件属主，ok 总为 false
+func fileOwner(info os.FileInfo) (uid, gid int, nlink uint64, ok bool) {
+	return 0, 0, 0, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package fsutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner 返回文件的属主、属组和硬链接数，无法获取时 ok 为 false
+func fileOwner(info os.FileInfo) (uid, gid int, nlink uint64, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	return int(st.Uid), int(st.Gid), uint64(st.Nlink), true
+}
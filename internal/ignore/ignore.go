@@ -0,0 +1,156 @@
+// Package ignore 解析 .gitignore 格式的忽略规则，并判断路径是否被忽略。
+// 支持注释、取反（!）、只匹配目录（结尾的 /）、锚定到所在目录的模式（包含 /）以及 **，
+// 不依赖 git 命令
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// rule 一条忽略规则
+type rule struct {
+	// 匹配模式，锚定时相对规则所在目录，否则只匹配文件名
+	glob string
+	// 以 ! 开头，重新包含之前被忽略的路径
+	negate bool
+	// 以 / 结尾，只匹配目录
+	dirOnly bool
+	// 包含 /，相对规则所在目录匹配
+	anchored bool
+}
+
+// Matcher 按目录保存的忽略规则。深层目录中的规则优先于上层目录，
+// 同一目录中后面的规则优先于前面的规则
+type Matcher struct {
+	// 键为规则所在目录相对根目录的路径（使用 /，根目录为 ""）
+	rules map[string][]rule
+}
+
+// New 创建空的规则集
+func New() *Matcher {
+	return &Matcher{rules: make(map[string][]rule)}
+}
+
+// AddFile 读取忽略文件，将其中的规则加入 dir 目录（相对根目录）。文件不存在时不做任何事
+func (m *Matcher) AddFile(dir, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	m.AddPatterns(dir, parseLines(data))
+	return nil
+}
+
+// AddPatterns 将 .gitignore 格式的模式加入 dir 目录（相对根目录），空行和注释会被忽略
+func (m *Matcher) AddPatterns(dir string, lines []string) {
+	dir = cleanDir(dir)
+	for _, line := range lines {
+		if r, ok := parseRule(line); ok {
+			m.rules[dir] = append(m.rules[dir], r)
+		}
+	}
+}
+
+// Match 判断相对根目录的路径是否被忽略，isDir 表示该路径是否为目录
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	if rel == "" || rel == "." || len(m.rules) == 0 {
+		return false
+	}
+
+	ignored := false
+	name := path.Base(rel)
+	// 从根目录开始依次检查路径的每一级上层目录中的规则，后匹配的规则优先
+	m.matchDir("", rel, name, isDir, &ignored)
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' {
+			m.matchDir(rel[:i], rel[i+1:], name, isDir, &ignored)
+		}
+	}
+	return ignored
+}
+
+// matchDir 用 dir 目录中的规则检查路径，sub 为路径相对 dir 的部分，name 为文件名。
+// 有规则匹配时更新 ignored
+func (m *Matcher) matchDir(dir, sub, name string, isDir bool, ignored *bool) {
+	for _, r := range m.rules[dir] {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		target := name
+		if r.anchored {
+			target = sub
+		}
+		if doublestar.MatchUnvalidated(r.glob, target) {
+			*ignored = !r.negate
+		}
+	}
+}
+
+// parseLines 按行拆分文件内容
+func parseLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// parseRule 解析一行 .gitignore 模式，空行、注释和无效模式返回 false
+func parseRule(line string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// 去掉结尾未转义的空格
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	// .gitignore 不支持 {a,b}，花括号按普通字符处理
+	line = strings.NewReplacer("{", `\{`, "}", `\}`).Replace(line)
+	if !doublestar.ValidatePattern(line) {
+		return rule{}, false
+	}
+	r.glob = line
+	return r, true
+}
+
+// cleanDir 规范化目录的相对路径，根目录为 ""
+func cleanDir(dir string) string {
+	dir = strings.Trim(filepath.ToSlash(dir), "/")
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,357 @@
+package journal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/file-replacer/internal/fsutil"
+)
+
+const (
+	// 运行记录所在的子目录
+	runsDir = "runs"
+	// 运行信息文件
+	metaFile = "run.json"
+	// 逐行追加的文件记录
+	entriesFile = "entries.jsonl"
+	// 原始内容备份目录
+	backupDir = "files"
+)
+
+// Meta 一次运行的基本信息
+type Meta struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	RootDir string    `json:"root_dir"`
+	// 扫描了多个根目录时的全部根目录，RootDir 为其中第一个
+	RootDirs []string `json:"root_dirs,omitempty"`
+}
+
+// Roots 返回运行扫描的所有根目录
+func (m Meta) Roots() []string {
+	if len(m.RootDirs) > 0 {
+		return m.RootDirs
+	}
+	return []string{m.RootDir}
+}
+
+// Entry 一个被修改文件的记录
+type Entry struct {
+	// 文件的绝对路径
+	Path string `json:"path"`
+	// 原始内容在运行目录中的备份文件名
+	Backup string `json:"backup"`
+	// 原始内容和替换后内容的 SHA-256
+	OriginalHash string `json:"original_sha256"`
+	NewHash      string `json:"new_sha256"`
+	// 原始修改时间，撤销时恢复
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Journal 记录一次运行中所有被修改文件的原始内容
+type Journal struct {
+	mu      sync.Mutex
+	dir     string
+	meta    Meta
+	entries *os.File
+	count   int
+}
+
+// Create 在 baseDir/runs 下创建一次新的运行记录，rootDirs 为本次扫描的根目录
+func Create(baseDir string, rootDirs []string) (*Journal, error) {
+	id, err := newRunID()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(baseDir, runsDir, id)
+	if err := os.MkdirAll(filepath.Join(dir, backupDir), 0755); err != nil {
+		return nil, err
+	}
+
+	meta := Meta{ID: id, Created: time.Now()}
+	for _, root := range rootDirs {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		meta.RootDirs = append(meta.RootDirs, root)
+	}
+	if len(meta.RootDirs) > 0 {
+		meta.RootDir = meta.RootDirs[0]
+	}
+	if len(meta.RootDirs) < 2 {
+		meta.RootDirs = nil
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, metaFile), data, 0644); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.OpenFile(filepath.Join(dir, entriesFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Journal{dir: dir, meta: meta, entries: entries}, nil
+}
+
+// ID 返回运行编号
+func (j *Journal) ID() string {
+	return j.meta.ID
+}
+
+// Count 返回已记录的文件数
+func (j *Journal) Count() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.count
+}
+
+// Record 在覆盖文件前备份其原始内容
+// 备份和记录都同步到磁盘后才返回，调用方随后再写入新内容
+func (j *Journal) Record(path string, original, updated []byte) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.count++
+	backup := fmt.Sprintf("%06d", j.count)
+	if err := writeSynced(filepath.Join(j.dir, backupDir, backup), original); err != nil {
+		return err
+	}
+
+	entry := Entry{
+		Path:         abs,
+		Backup:       backup,
+		OriginalHash: hashOf(original),
+		NewHash:      hashOf(updated),
+		ModTime:      info.ModTime(),
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.entries.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.entries.Sync()
+}
+
+// Close 结束记录，没有任何文件被修改时删除本次运行目录
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.entries.Close(); err != nil {
+		return err
+	}
+	if j.count == 0 {
+		return os.RemoveAll(j.dir)
+	}
+	return nil
+}
+
+// Run 一次已完成运行的记录
+type Run struct {
+	Meta
+	Entries []Entry
+}
+
+// Load 读取指定编号的运行记录
+func Load(baseDir, id string) (*Run, error) {
+	dir := filepath.Join(baseDir, runsDir, id)
+	data, err := os.ReadFile(filepath.Join(dir, metaFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("找不到运行记录 %s", id)
+		}
+		return nil, err
+	}
+
+	run := &Run{}
+	if err := json.Unmarshal(data, &run.Meta); err != nil {
+		return nil, fmt.Errorf("运行记录 %s 已损坏: %v", id, err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, entriesFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("运行记录 %s 已损坏: %v", id, err)
+		}
+		run.Entries = append(run.Entries, entry)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// List 按时间顺序列出所有运行记录
+func List(baseDir string) ([]Meta, error) {
+	dirs, err := os.ReadDir(filepath.Join(baseDir, runsDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var metas []Meta
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(baseDir, runsDir, d.Name(), metaFile))
+		if err != nil {
+			continue
+		}
+		var meta Meta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			continue
+		}
+		metas = append(metas, meta)
+	}
+	sort.Slice(metas, func(i, k int) bool { return metas[i].Created.Before(metas[k].Created) })
+	return metas, nil
+}
+
+// UndoResult 撤销操作的结果
+type UndoResult struct {
+	// 已恢复的文件
+	Restored []string
+	// 内容已是原始状态而跳过的文件
+	Unchanged []string
+}
+
+// ConflictError 表示有文件在运行后又被修改过
+type ConflictError struct {
+	Files []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("以下 %d 个文件在运行后已被修改，拒绝撤销:\n  %s",
+		len(e.Files), strings.Join(e.Files, "\n  "))
+}
+
+// Undo 将运行中修改过的文件恢复为原始内容
+// 任一文件的当前内容既不是替换后的内容也不是原始内容时，返回 ConflictError 且不做任何恢复，
+// force 为 true 时忽略此检查
+func Undo(baseDir, id string, force bool) (*UndoResult, error) {
+	run, err := Load(baseDir, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先检查所有文件，确认没有冲突后再恢复
+	var restore []Entry
+	result := &UndoResult{}
+	var conflicts []string
+	for _, entry := range run.Entries {
+		current, err := os.ReadFile(entry.Path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				conflicts = append(conflicts, entry.Path+" (已删除)")
+				continue
+			}
+			return nil, err
+		}
+
+		switch hashOf(current) {
+		case entry.NewHash:
+			restore = append(restore, entry)
+		case entry.OriginalHash:
+			result.Unchanged = append(result.Unchanged, entry.Path)
+		default:
+			if force {
+				restore = append(restore, entry)
+				continue
+			}
+			conflicts = append(conflicts, entry.Path)
+		}
+	}
+	if len(conflicts) > 0 && !force {
+		return nil, &ConflictError{Files: conflicts}
+	}
+
+	dir := filepath.Join(baseDir, runsDir, id)
+	for _, entry := range restore {
+		original, err := os.ReadFile(filepath.Join(dir, backupDir, entry.Backup))
+		if err != nil {
+			return result, fmt.Errorf("读取 %s 的备份失败: %v", entry.Path, err)
+		}
+		if err := fsutil.WriteFileAtomic(entry.Path, original, false); err != nil {
+			return result, fmt.Errorf("恢复 %s 失败: %v", entry.Path, err)
+		}
+		if !entry.ModTime.IsZero() {
+			os.Chtimes(entry.Path, entry.ModTime, entry.ModTime)
+		}
+		result.Restored = append(result.Restored, entry.Path)
+	}
+	return result, nil
+}
+
+// newRunID 生成以时间开头、便于排序的运行编号
+func newRunID() (string, error) {
+	buf := make([]byte, 3)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf), nil
+}
+
+// hashOf 计算内容的 SHA-256
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeSynced 写入文件并同步到磁盘
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,255 @@
+// Package matcher 实现基于 Aho-Corasick 自动机的多模式字面字符串匹配，
+// 只需扫描一次内容即可找出所有模式的匹配
+package matcher
+
+// Match 一处匹配
+type Match struct {
+	// 匹配在内容中的字节范围 [Start, End)
+	Start int
+	End   int
+	// 匹配的模式序号，多个模式相同时为最小的序号
+	Pattern int
+}
+
+// edge 字典树的一条边
+type edge struct {
+	b    byte
+	next int32
+}
+
+// node 自动机的一个状态，对应某些模式的一个公共前缀
+type node struct {
+	edges []edge
+	// 失败链接：当前前缀的最长真后缀所对应的状态
+	fail int32
+	// 沿失败链最近的有模式结束的状态，没有时为 -1
+	dict int32
+	// 在此结束的模式序号，没有时为 -1
+	out int32
+	// 前缀长度
+	depth int32
+	// 以此前缀开头的模式序号的最小值和最大值
+	lo, hi int32
+}
+
+// Matcher 由一组模式构建的自动机，构建后可并发使用
+type Matcher struct {
+	patterns []string
+	nodes    []node
+	// 根状态的转移表，大多数字节都从根状态开始，单独存放以加快查找
+	root [256]int32
+	// 最长模式的长度
+	maxLen int
+}
+
+// New 由模式列表构建自动机，空模式被忽略
+func New(patterns []string) *Matcher {
+	m := &Matcher{
+		patterns: patterns,
+		nodes:    []node{{dict: -1, out: -1, lo: -1, hi: -1}},
+	}
+
+	// 构建字典树
+	for i, p := range patterns {
+		if p == "" {
+			continue
+		}
+		if len(p) > m.maxLen {
+			m.maxLen = len(p)
+		}
+		state := int32(0)
+		for j := 0; j < len(p); j++ {
+			next := m.child(state, p[j])
+			if next == 0 {
+				next = int32(len(m.nodes))
+				m.nodes = append(m.nodes, node{dict: -1, out: -1, depth: int32(j + 1), lo: int32(i), hi: int32(i)})
+				if state == 0 {
+					m.root[p[j]] = next
+				} else {
+					m.nodes[state].edges = append(m.nodes[state].edges, edge{b: p[j], next: next})
+				}
+			}
+			state = next
+			n := &m.nodes[state]
+			if int32(i) < n.lo {
+				n.lo = int32(i)
+			}
+			if int32(i) > n.hi {
+				n.hi = int32(i)
+			}
+		}
+		if m.nodes[state].out < 0 {
+			m.nodes[state].out = int32(i)
+		}
+	}
+
+	// 按层次遍历计算失败链接
+	queue := make([]int32, 0, len(m.nodes))
+	for b := 0; b < 256; b++ {
+		if next := m.root[b]; next != 0 {
+			queue = append(queue, next)
+		}
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for _, e := range m.nodes[state].edges {
+			fail := m.next(m.nodes[state].fail, e.b)
+			child := &m.nodes[e.next]
+			child.fail = fail
+			if m.nodes[fail].out >= 0 {
+				child.dict = fail
+			} else {
+				child.dict = m.nodes[fail].dict
+			}
+			queue = append(queue, e.next)
+		}
+	}
+	return m
+}
+
+// Patterns 返回构建自动机的模式列表
+func (m *Matcher) Patterns() []string {
+	return m.patterns
+}
+
+// child 返回字典树中状态经字节 b 到达的子状态，没有时返回 0
+func (m *Matcher) child(state int32, b byte) int32 {
+	if state == 0 {
+		return m.root[b]
+	}
+	for _, e := range m.nodes[state].edges {
+		if e.b == b {
+			return e.next
+		}
+	}
+	return 0
+}
+
+// next 返回自动机从状态读入字节 b 后的状态
+func (m *Matcher) next(state int32, b byte) int32 {
+	for state != 0 {
+		for _, e := range m.nodes[state].edges {
+			if e.b == b {
+				return e.next
+			}
+		}
+		state = m.nodes[state].fail
+	}
+	return m.root[b]
+}
+
+// FindOverlapping 找出内容中所有模式的所有匹配（包括互相重叠的），按结束位置依次调用 fn，
+// fn 返回 false 时停止查找
+func (m *Matcher) FindOverlapping(s string, fn func(Match) bool) {
+	if m.maxLen == 0 {
+		return
+	}
+	state := int32(0)
+	for i := 0; i < len(s); i++ {
+		state = m.next(state, s[i])
+		for n := state; n > 0; n = m.nodes[n].dict {
+			if out := m.nodes[n].out; out >= 0 {
+				depth := int(m.nodes[n].depth)
+				if !fn(Match{Start: i + 1 - depth, End: i + 1, Pattern: int(out)}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// FindAll 找出内容中互不重叠的所有匹配，按位置升序排列。
+// 从左到右选取匹配：起始位置最靠前的优先，起始位置相同时最长的优先
+func (m *Matcher) FindAll(s string) []Match {
+	var (
+		matches []Match
+		// 还不能确定是否选取的候选匹配
+		pending []Match
+		// 最近一个选取的匹配的结束位置
+		lastEnd int
+	)
+
+	// settle 选取起始位置在 limit 之前的候选匹配：之后找到的匹配起始位置都不会早于 limit
+	settle := func(limit int) {
+		for len(pending) > 0 {
+			best := 0
+			for i, p := range pending[1:] {
+				if better(p, pending[best]) {
+					best = i + 1
+				}
+			}
+			if pending[best].Start >= limit {
+				return
+			}
+			chosen := pending[best]
+			matches = append(matches, chosen)
+			lastEnd = chosen.End
+
+			// 去掉与选取的匹配重叠的候选
+			kept := pending[:0]
+			for _, p := range pending {
+				if p.Start >= lastEnd {
+					kept = append(kept, p)
+				}
+			}
+			pending = kept
+		}
+	}
+
+	if m.maxLen == 0 {
+		return nil
+	}
+	state := int32(0)
+	for i := 0; i < len(s); i++ {
+		state = m.next(state, s[i])
+		for n := state; n > 0; n = m.nodes[n].dict {
+			if out := m.nodes[n].out; out >= 0 {
+				start := i + 1 - int(m.nodes[n].depth)
+				if start >= lastEnd {
+					pending = append(pending, Match{Start: start, End: i + 1, Pattern: int(out)})
+				}
+			}
+		}
+		if len(pending) > 0 {
+			// 之后的匹配至少在 i+2 结束，起始位置不早于 i+2-maxLen
+			settle(i + 2 - m.maxLen)
+		}
+	}
+	settle(len(s) + 1)
+	return matches
+}
+
+// better 判断匹配 a 是否优先于 b：起始位置靠前、更长、模式序号更小
+func better(a, b Match) bool {
+	if a.Start != b.Start {
+		return a.Start < b.Start
+	}
+	if a.End != b.End {
+		return a.End > b.End
+	}
+	return a.Pattern < b.Pattern
+}
+
+// SuffixPrefix 查找以 s 的某个非空后缀（包括 s 本身）开头的模式，
+// 返回这些模式序号的最小值和最大值，没有时 ok 为 false。
+// 可用于判断 s 之后紧跟的内容是否可能与 s 的末尾一起组成某个模式
+func (m *Matcher) SuffixPrefix(s string) (lo, hi int, ok bool) {
+	if m.maxLen == 0 {
+		return 0, 0, false
+	}
+	state := int32(0)
+	for i := 0; i < len(s); i++ {
+		state = m.next(state, s[i])
+	}
+	for n := state; n != 0; n = m.nodes[n].fail {
+		if !ok || int(m.nodes[n].lo) < lo {
+			lo = int(m.nodes[n].lo)
+		}
+		if !ok || int(m.nodes[n].hi) > hi {
+			hi = int(m.nodes[n].hi)
+		}
+		ok = true
+	}
+	return lo, hi, ok
+}
@@ -0,0 +1,29 @@
+package replacer
+
+// 判断二进制文件时检查的字节数
+const sniffLen = 8000
+
+// 控制字符占比超过该值时视为二进制文件
+const binaryThreshold = 0.1
+
+// isBinary 根据开头的内容判断是否为二进制文件：出现 NUL 字节，或控制字符的占比过高时视为二进制。
+// 无效的 UTF-8 字节不计入，GBK 等其他编码的文本不会被当作二进制文件
+func isBinary(content []byte) bool {
+	if len(content) > sniffLen {
+		content = content[:sniffLen]
+	}
+	if len(content) == 0 {
+		return false
+	}
+
+	control := 0
+	for _, c := range content {
+		if c == 0 {
+			return true
+		}
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != '\b' && c != 0x1b {
+			control++
+		}
+	}
+	return float64(control)/float64(len(content)) > binaryThreshold
+}
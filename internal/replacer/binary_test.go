@@ -0,0 +1,30 @@
+package replacer
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestIsBinary 检查 GBK 等非 UTF-8 编码的文本不会被当作二进制文件
+func TestIsBinary(t *testing.T) {
+	random := make([]byte, 4096)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{"UTF-8 文本", []byte("<p>中文页面</p>\n<a href=\"https://qqt.cmicrwx.cn\">链接</a>\n"), false},
+		// "这是一个中文页面" 的 GBK 编码
+		{"GBK 文本", append([]byte("<p>"), 0xd5, 0xe2, 0xca, 0xc7, 0xd2, 0xbb, 0xb8, 0xf6, 0xd6, 0xd0, 0xce, 0xc4, 0xd2, 0xb3, 0xc3, 0xe6, '<', '/', 'p', '>', '\n'), false},
+		{"NUL 字节", []byte("abc\x00def"), true},
+		{"随机字节", random, true},
+		{"控制字符", []byte("\x01\x02\x03\x04abc"), true},
+	}
+	for _, tt := range tests {
+		if got := isBinary(tt.content); got != tt.want {
+			t.Errorf("%s: isBinary 为 %v，应为 %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package replacer
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yourusername/file-replacer/internal/config"
+)
+
+// caseRule 不区分大小写的字面规则，替换内容沿用每处匹配的大小写形式
+type caseRule struct {
+	cfg config.ReplaceItem
+	re  *regexp.Regexp
+}
+
+// newCaseRule 编译不区分大小写的字面规则
+func newCaseRule(item config.ReplaceItem) (*caseRule, error) {
+	re, err := regexp.Compile("(?i)" + regexp.QuoteMeta(item.SearchString))
+	if err != nil {
+		return nil, err
+	}
+	return &caseRule{cfg: item, re: re}, nil
+}
+
+func (r *caseRule) item() config.ReplaceItem {
+	return r.cfg
+}
+
+// samples 返回查找内容原样、全小写、全大写和首字母大写的写法
+func (r *caseRule) samples() []sample {
+	search := r.cfg.SearchString
+	forms := []string{search, strings.ToLower(search), strings.ToUpper(search)}
+	if lower := strings.ToLower(search); lower != "" {
+		c, n := utf8.DecodeRuneInString(lower)
+		forms = append(forms, string(unicode.ToUpper(c))+lower[n:])
+	}
+
+	var samples []sample
+	seen := make(map[string]bool)
+	for _, form := range forms {
+		if seen[form] {
+			continue
+		}
+		seen[form] = true
+		samples = append(samples, sample{text: form, replacement: preserveCase(form, r.cfg.ReplaceString)})
+	}
+	return samples
+}
+
+func (r *caseRule) findAll(content string) []match {
+	locs := r.re.FindAllStringIndex(content, -1)
+	if len(locs) == 0 {
+		return nil
+	}
+
+	matches := make([]match, 0, len(locs))
+	for _, loc := range locs {
+		matches = append(matches, match{
+			start:       loc[0],
+			end:         loc[1],
+			replacement: preserveCase(content[loc[0]:loc[1]], r.cfg.ReplaceString),
+		})
+	}
+	return matches
+}
+
+// preserveCase 按匹配文本的大小写形式调整替换内容：
+// 全部小写时替换内容转为小写，全部大写时转为大写（如 FOO→BAR），
+// 首字母大写时替换内容首字母大写（如 Foo→Bar），其他混合形式保持替换内容原样
+func preserveCase(matched, replace string) string {
+	var upper, lower int
+	for _, c := range matched {
+		switch {
+		case unicode.IsUpper(c):
+			upper++
+		case unicode.IsLower(c):
+			lower++
+		}
+	}
+
+	switch {
+	case replace == "" || upper == 0 && lower == 0:
+		return replace
+	case upper == 0:
+		return strings.ToLower(replace)
+	case lower == 0 && upper > 1:
+		return strings.ToUpper(replace)
+	}
+
+	// 首字母大写，其余字母小写
+	first, size := utf8.DecodeRuneInString(matched)
+	if unicode.IsUpper(first) && !hasUpper(matched[size:]) {
+		c, n := utf8.DecodeRuneInString(replace)
+		return string(unicode.ToUpper(c)) + replace[n:]
+	}
+	return replace
+}
+
+// hasUpper 判断字符串中是否有大写字母
+func hasUpper(s string) bool {
+	for _, c := range s {
+		if unicode.IsUpper(c) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package replacer
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/failures"
+)
+
+// Occurrence 一处匹配的位置
+type Occurrence struct {
+	Path string
+	// 行号和列号均从 1 开始，列号按字符计算
+	Line   int
+	Column int
+	// 匹配的替换项序号（从 1 开始）
+	Rule int
+	// 匹配到的文本
+	Text string
+}
+
+// CheckResult 检查结果
+type CheckResult struct {
+	// 所有匹配，按文件路径和位置排序
+	Occurrences []Occurrence
+	// 匹配到内容的文件数
+	Files int
+	// 读取失败的文件及原因
+	Failed []failures.Failure
+	// 作为二进制文件跳过的文件，按路径排序
+	Skipped []string
+}
+
+// Checker 只查找替换项的匹配位置，不修改任何文件
+type Checker struct {
+	config *config.Config
+}
+
+// NewChecker 创建检查器
+func NewChecker(cfg *config.Config) *Checker {
+	// 同样处理旧版替换项
+	cfg.MergeLegacyItem()
+
+	return &Checker{config: cfg}
+}
+
+// Check 在指定文件中查找所有替换项的匹配
+// 每个替换项都在文件的原始内容上独立查找，不受其他替换项的影响
+func (c *Checker) Check(files []string) (*CheckResult, error) {
+	rules, err := compileRules(c.config.ReplaceItems)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckResult{}
+	var mu sync.Mutex
+	var failed failures.List
+
+	fileChan := make(chan string, len(files))
+	for _, file := range files {
+		fileChan <- file
+	}
+	close(fileChan)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.config.Threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range fileChan {
+				// fail-fast 策略下出错后不再检查剩余文件
+				if c.config.OnError == config.OnErrorFailFast && failed.Len() > 0 {
+					continue
+				}
+				occurrences, skipped, err := c.checkFile(rules, file)
+				if err != nil {
+					failed.Add(file, err)
+					continue
+				}
+				mu.Lock()
+				if skipped {
+					result.Skipped = append(result.Skipped, file)
+				}
+				if len(occurrences) > 0 {
+					result.Files++
+					result.Occurrences = append(result.Occurrences, occurrences...)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result.Failed = failed.Failures()
+	if first := failed.First(); first != nil && c.config.OnError == config.OnErrorFailFast {
+		return result, fmt.Errorf("读取文件 %s 失败，已停止检查剩余文件: %v", first.Path, first.Err)
+	}
+
+	sort.Strings(result.Skipped)
+	sort.Slice(result.Occurrences, func(i, j int) bool {
+		a, b := result.Occurrences[i], result.Occurrences[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Rule < b.Rule
+	})
+	return result, nil
+}
+
+// checkFile 查找单个文件中的所有匹配，作为二进制文件跳过时 skipped 为 true
+func (c *Checker) checkFile(rules []rule, filePath string) (occurrences []Occurrence, skipped bool, err error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, false, err
+	}
+	if !c.config.Binary && isBinary(content) {
+		return nil, true, nil
+	}
+
+	text := string(content)
+
+	// 先收集所有匹配的字节偏移，再统一换算为行号和列号
+	type found struct {
+		offset int
+		rule   int
+		text   string
+	}
+	var matches []found
+	for i, r := range rules {
+		for _, m := range r.findAll(text) {
+			matches = append(matches, found{offset: m.start, rule: i + 1, text: text[m.start:m.end]})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].offset < matches[j].offset })
+
+	occurrences = make([]Occurrence, 0, len(matches))
+	line, lineStart, offset := 1, 0, 0
+	for _, m := range matches {
+		for ; offset < m.offset; offset++ {
+			if text[offset] == '\n' {
+				line++
+				lineStart = offset + 1
+			}
+		}
+		occurrences = append(occurrences, Occurrence{
+			Path:   filePath,
+			Line:   line,
+			Column: utf8.RuneCountInString(text[lineStart:m.offset]) + 1,
+			Rule:   m.rule,
+			Text:   m.text,
+		})
+	}
+	return occurrences, false, nil
+}
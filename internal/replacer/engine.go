@@ -0,0 +1,48 @@
+package replacer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yourusername/file-replacer/internal/config"
+)
+
+// 替换引擎名称
+const (
+	// EngineBuffered 使用有缓冲通道和固定工作协程的 Replacer（默认）
+	EngineBuffered = "buffered"
+	// EngineUnbuffered 使用无缓冲结果通道的 UnbufferedReplacer
+	EngineUnbuffered = "unbuffered"
+)
+
+// Engine 两种替换器的公共接口
+type Engine interface {
+	// Replace 对指定文件列表执行替换操作，ctx 被取消后不再处理剩余文件
+	Replace(ctx context.Context, files []string) error
+	// ReplaceStream 对通道中的文件执行替换，直到通道关闭，可与扫描同时进行
+	ReplaceStream(ctx context.Context, files <-chan string) error
+	// Summary 返回最近一次替换的汇总
+	Summary() *Summary
+}
+
+// NewEngine 根据配置创建替换引擎
+func NewEngine(cfg *config.Config) (Engine, error) {
+	switch cfg.Engine {
+	case "", EngineBuffered:
+		return NewReplacer(cfg), nil
+	case EngineUnbuffered:
+		return NewUnbufferedReplacer(cfg), nil
+	default:
+		return nil, fmt.Errorf("未知的替换引擎: %s", cfg.Engine)
+	}
+}
+
+// fileChannel 将文件列表放入一个已关闭的有缓冲通道
+func fileChannel(files []string) <-chan string {
+	ch := make(chan string, len(files))
+	for _, file := range files {
+		ch <- file
+	}
+	close(ch)
+	return ch
+}
@@ -0,0 +1,202 @@
+package replacer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/file-replacer/internal/config"
+)
+
+// hostRule 主机名规则：不区分大小写，只匹配两侧都不是主机名字符的完整主机名，
+// 可同时改写主机名前的 http/https 协议和其后的端口
+type hostRule struct {
+	cfg config.ReplaceItem
+	// 匹配主机名本身及（开启时）点号转义写法的表达式
+	re *regexp.Regexp
+}
+
+// newHostRule 编译主机名规则
+func newHostRule(item config.ReplaceItem) (*hostRule, error) {
+	pattern := regexp.QuoteMeta(item.SearchString)
+	if item.EscapedDots {
+		// 同时匹配正则或字符串中写作 qqt\.cmicrwx\.cn 的主机名
+		escaped := strings.ReplaceAll(regexp.QuoteMeta(item.SearchString), `\.`, `\\\.`)
+		pattern = pattern + "|" + escaped
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &hostRule{cfg: item, re: re}, nil
+}
+
+// validateHostItem 检查主机名规则的选项
+func validateHostItem(item config.ReplaceItem) error {
+	for i := 0; i < len(item.Scheme); i++ {
+		c := item.Scheme[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return fmt.Errorf("协议 '%s' 无效", item.Scheme)
+		}
+	}
+	for i := 0; i < len(item.Port); i++ {
+		if item.Port[i] < '0' || item.Port[i] > '9' {
+			return fmt.Errorf("端口 '%s' 无效", item.Port)
+		}
+	}
+	return nil
+}
+
+func (r *hostRule) item() config.ReplaceItem {
+	return r.cfg
+}
+
+// samples 返回主机名本身及（开启时）点号转义的写法，协议和端口的改写不影响主机名部分
+func (r *hostRule) samples() []sample {
+	samples := []sample{{text: r.cfg.SearchString, replacement: r.cfg.ReplaceString}}
+	if r.cfg.EscapedDots && strings.Contains(r.cfg.SearchString, ".") {
+		samples = append(samples, sample{
+			text:        strings.ReplaceAll(r.cfg.SearchString, ".", `\.`),
+			replacement: strings.ReplaceAll(r.cfg.ReplaceString, ".", `\.`),
+		})
+	}
+	return samples
+}
+
+func (r *hostRule) findAll(content string) []match {
+	var matches []match
+	for _, loc := range r.re.FindAllStringIndex(content, -1) {
+		start, end := loc[0], loc[1]
+		if !hostBoundaryBefore(content, start) || !hostBoundaryAfter(content, end) {
+			continue
+		}
+
+		escaped := strings.Contains(content[start:end], `\.`)
+		replacement := r.cfg.ReplaceString
+		if escaped {
+			replacement = strings.ReplaceAll(replacement, ".", `\.`)
+		}
+
+		// 改写主机名前的协议
+		if r.cfg.Scheme != "" {
+			if n := schemePrefixLen(content[:start]); n > 0 {
+				start -= n
+				replacement = r.cfg.Scheme + "://" + replacement
+			}
+		}
+		// 改写主机名后的端口
+		if r.cfg.Port != "" {
+			if n := portSuffixLen(content[end:]); n > 0 {
+				end += n
+				replacement += ":" + r.cfg.Port
+			}
+		}
+
+		// 扩展后也不会与上一处匹配重叠：上一处匹配之后紧跟协议名时，已因边界检查被排除
+		matches = append(matches, match{start: start, end: end, replacement: replacement})
+	}
+	return matches
+}
+
+// isHostChar 判断字节是否可以出现在主机名的标签中
+func isHostChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// hostBoundaryBefore 判断 pos 之前是否为主机名的边界：前面不是标签字符，也不是点号
+// （否则匹配的只是更长主机名的一部分，如 xqqt.cmicrwx.cn 或 a.qqt.cmicrwx.cn）。
+// 前面是百分号编码（如 https%3A%2F%2Fqqt.cmicrwx.cn）时按解码后的字符判断
+func hostBoundaryBefore(content string, pos int) bool {
+	if pos == 0 {
+		return true
+	}
+	c := content[pos-1]
+	if pos >= 3 && content[pos-3] == '%' {
+		if decoded, ok := decodePercent(content[pos-2 : pos]); ok {
+			c = decoded
+		}
+	}
+	return !isHostChar(c) && c != '.'
+}
+
+// decodePercent 解码百分号编码中 % 之后的两位十六进制数
+func decodePercent(hex string) (byte, bool) {
+	var c byte
+	for i := 0; i < 2; i++ {
+		h := hex[i]
+		switch {
+		case h >= '0' && h <= '9':
+			h -= '0'
+		case h >= 'a' && h <= 'f':
+			h -= 'a' - 10
+		case h >= 'A' && h <= 'F':
+			h -= 'A' - 10
+		default:
+			return 0, false
+		}
+		c = c<<4 | h
+	}
+	return c, true
+}
+
+// hostBoundaryAfter 判断 pos 之后是否为主机名的边界：后面不是标签字符，
+// 也不是紧跟标签字符的点号（如 qqt.cmicrwx.cn.evil.com），句末的点号不影响匹配。百分号编码的字符按解码后的字符判断
+func hostBoundaryAfter(content string, pos int) bool {
+	rest := content[pos:]
+	if rest == "" {
+		return true
+	}
+	if isHostChar(rest[0]) {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(rest, "."):
+		rest = rest[1:]
+	case strings.HasPrefix(rest, `\.`):
+		rest = rest[2:]
+	case len(rest) >= 3 && rest[0] == '%':
+		// 百分号编码的标签字符或点号（如 %2E）同样说明主机名还没有结束
+		c, ok := decodePercent(rest[1:3])
+		if !ok || !isHostChar(c) && c != '.' {
+			return true
+		}
+		if isHostChar(c) {
+			return false
+		}
+		rest = rest[3:]
+	default:
+		return true
+	}
+	return rest == "" || !isHostChar(rest[0])
+}
+
+// schemePrefixLen 返回 before 末尾 "http://" 或 "https://"（不区分大小写）的长度，没有时返回 0
+func schemePrefixLen(before string) int {
+	for _, scheme := range []string{"https://", "http://"} {
+		n := len(scheme)
+		if len(before) < n || !strings.EqualFold(before[len(before)-n:], scheme) {
+			continue
+		}
+		// 协议名本身也需要是完整的单词
+		if len(before) > n && isHostChar(before[len(before)-n-1]) {
+			return 0
+		}
+		return n
+	}
+	return 0
+}
+
+// portSuffixLen 返回 after 开头 ":端口号" 的长度，没有时返回 0
+func portSuffixLen(after string) int {
+	if !strings.HasPrefix(after, ":") {
+		return 0
+	}
+	n := 1
+	for n < len(after) && after[n] >= '0' && after[n] <= '9' {
+		n++
+	}
+	if n == 1 || (n < len(after) && isHostChar(after[n])) {
+		return 0
+	}
+	return n
+}
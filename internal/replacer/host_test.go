@@ -0,0 +1,38 @@
+package replacer
+
+import (
+	"testing"
+
+	"github.com/yourusername/file-replacer/internal/config"
+)
+
+// TestHostRuleBoundaries 检查主机名规则只匹配完整的主机名，百分号编码按解码后的字符判断边界
+func TestHostRuleBoundaries(t *testing.T) {
+	rules, err := compileRules([]config.ReplaceItem{{
+		SearchString:  "qqt.cmicrwx.cn",
+		ReplaceString: "qqt.cmicvip.cn",
+		Type:          config.RuleHost,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"https://qqt.cmicrwx.cn/path":             "https://qqt.cmicvip.cn/path",
+		"https%3A%2F%2Fqqt.cmicrwx.cn%2Fpath":     "https%3A%2F%2Fqqt.cmicvip.cn%2Fpath",
+		"https%3a%2f%2fqqt.cmicrwx.cn%3A8080":     "https%3a%2f%2fqqt.cmicvip.cn%3A8080",
+		"xqqt.cmicrwx.cn":                         "xqqt.cmicrwx.cn",
+		"a.qqt.cmicrwx.cn":                        "a.qqt.cmicrwx.cn",
+		"qqt.cmicrwx.cn.evil.com":                 "qqt.cmicrwx.cn.evil.com",
+		"https%3A%2F%2Fa%2Eqqt.cmicrwx.cn":        "https%3A%2F%2Fa%2Eqqt.cmicrwx.cn",
+		"https%3A%2F%2F%41qqt.cmicrwx.cn":         "https%3A%2F%2F%41qqt.cmicrwx.cn",
+		"https%3A%2F%2Fqqt.cmicrwx.cn%2Eevil.com": "https%3A%2F%2Fqqt.cmicrwx.cn%2Eevil.com",
+		"https%3A%2F%2Fqqt.cmicrwx.cn%41":         "https%3A%2F%2Fqqt.cmicrwx.cn%41",
+	}
+	for content, want := range tests {
+		got, _ := applyOneByOne(rules, content)
+		if got != want {
+			t.Errorf("%q 替换后为 %q，应为 %q", content, got, want)
+		}
+	}
+}
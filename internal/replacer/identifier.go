@@ -0,0 +1,143 @@
+package replacer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/matcher"
+)
+
+// identifierStyle 标识符的一种命名风格
+type identifierStyle struct {
+	name   string
+	format func(words []string) string
+}
+
+// identifierStyles 标识符规则生成的命名风格，多种风格写法相同时保留排在前面的
+var identifierStyles = []identifierStyle{
+	{"camel", func(words []string) string { return lowerFirst(joinTitle(words)) }},
+	{"pascal", joinTitle},
+	{"snake", func(words []string) string { return strings.Join(words, "_") }},
+	{"screaming", func(words []string) string { return strings.ToUpper(strings.Join(words, "_")) }},
+	{"kebab", func(words []string) string { return strings.Join(words, "-") }},
+	{"dot", func(words []string) string { return strings.Join(words, ".") }},
+}
+
+// identifierRule 标识符规则：把查找和替换的词组展开为各种命名风格（userAccount、UserAccount、
+// user_account、USER_ACCOUNT、user-account、user.account），每种风格替换为同样风格的写法
+type identifierRule struct {
+	cfg          config.ReplaceItem
+	styles       []string
+	replacements []string
+	matcher      *matcher.Matcher
+}
+
+// newIdentifierRule 拆分查找和替换内容中的单词，生成各命名风格的写法
+func newIdentifierRule(item config.ReplaceItem) (*identifierRule, error) {
+	searchWords := splitWords(item.SearchString)
+	if len(searchWords) == 0 {
+		return nil, fmt.Errorf("查找内容 '%s' 中没有单词", item.SearchString)
+	}
+	replaceWords := splitWords(item.ReplaceString)
+
+	r := &identifierRule{cfg: item}
+	var searches []string
+	seen := make(map[string]bool)
+	for _, style := range identifierStyles {
+		search := style.format(searchWords)
+		if seen[search] {
+			continue
+		}
+		seen[search] = true
+		searches = append(searches, search)
+		r.styles = append(r.styles, style.name)
+		r.replacements = append(r.replacements, style.format(replaceWords))
+	}
+	r.matcher = matcher.New(searches)
+	return r, nil
+}
+
+func (r *identifierRule) item() config.ReplaceItem {
+	return r.cfg
+}
+
+func (r *identifierRule) samples() []sample {
+	searches := r.matcher.Patterns()
+	samples := make([]sample, len(searches))
+	for i, search := range searches {
+		samples[i] = sample{text: search, replacement: r.replacements[i]}
+	}
+	return samples
+}
+
+func (r *identifierRule) findAll(content string) []match {
+	found := r.matcher.FindAll(content)
+	if len(found) == 0 {
+		return nil
+	}
+	matches := make([]match, len(found))
+	for i, f := range found {
+		matches[i] = match{
+			start:       f.Start,
+			end:         f.End,
+			replacement: r.replacements[f.Pattern],
+			variant:     r.styles[f.Pattern],
+		}
+	}
+	return matches
+}
+
+// splitWords 将词组拆分为小写的单词，支持空格、下划线、连字符、点号分隔，以及驼峰写法
+// （userAccount、HTTPServer 分别拆为 user account、http server）
+func splitWords(phrase string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(phrase)
+	for i, c := range runes {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(c) && len(current) > 0 {
+			prev := current[len(current)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// 小写或数字后的大写字母开始新单词；连续大写字母中，后面跟小写字母的那个开始新单词
+			if !unicode.IsUpper(prev) || nextLower {
+				flush()
+			}
+		}
+		current = append(current, c)
+	}
+	flush()
+	return words
+}
+
+// joinTitle 将每个单词首字母大写后连接
+func joinTitle(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		c, size := utf8.DecodeRuneInString(w)
+		b.WriteRune(unicode.ToUpper(c))
+		b.WriteString(w[size:])
+	}
+	return b.String()
+}
+
+// lowerFirst 将首字母改为小写
+func lowerFirst(s string) string {
+	c, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToLower(c)) + s[size:]
+}
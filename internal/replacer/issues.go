@@ -0,0 +1,201 @@
+package replacer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/matcher"
+)
+
+// 替换项问题的类型
+const (
+	// IssueDuplicate 前面的替换项完整匹配后面替换项的查找内容，但替换内容不同
+	IssueDuplicate = "duplicate"
+	// IssueChain 替换内容包含另一个替换项的查找内容，替换结果会被再次替换
+	IssueChain = "chain"
+	// IssueNonIdempotent 替换内容包含自身的查找内容，重复运行会再次替换
+	IssueNonIdempotent = "non-idempotent"
+	// IssueSubstring 查找内容是另一个替换项查找内容的一部分
+	IssueSubstring = "substring"
+)
+
+// RuleIssue 替换项之间的潜在问题
+type RuleIssue struct {
+	Kind string
+	// 涉及的替换项序号（从 1 开始）
+	Rules   []int
+	Message string
+}
+
+// RuleIssues 检查替换项之间的冲突和连锁替换，返回按替换项序号排列的问题列表；替换项无效时返回错误。
+// 检查使用与替换相同的编译规则，whole_word、ignore_case、主机名、标识符和编码写法都按实际的匹配方式判断。
+// 正则替换项只检查其表达式能否匹配其他替换项的替换内容，替换内容按原样检查，不展开捕获组引用
+func RuleIssues(items []config.ReplaceItem) ([]RuleIssue, error) {
+	rules, err := compileRules(items)
+	if err != nil {
+		return nil, err
+	}
+	c := newIssueChecker(rules)
+
+	var issues []RuleIssue
+	for j := range rules {
+		issues = append(issues, c.searchIssues(j)...)
+	}
+	for i := range rules {
+		issues = append(issues, c.replaceIssues(i)...)
+	}
+
+	sort.SliceStable(issues, func(a, b int) bool {
+		return issues[a].Rules[0] < issues[b].Rules[0]
+	})
+	return issues, nil
+}
+
+// issueChecker 检查替换项问题时，先用各规则写法的小写形式构建自动机，筛选可能匹配某段文本的规则，
+// 再用规则本身确认，替换项很多时也不必逐对调用规则
+type issueChecker struct {
+	rules   []rule
+	samples [][]sample
+	matcher *matcher.Matcher
+	// 自动机中每个模式对应的规则序号
+	owners [][]int
+	// 无法列举写法、总是直接检查的规则
+	always []int
+}
+
+func newIssueChecker(rules []rule) *issueChecker {
+	c := &issueChecker{rules: rules, samples: make([][]sample, len(rules))}
+	var patterns []string
+	index := make(map[string]int)
+	for i, r := range rules {
+		c.samples[i] = r.samples()
+		if r.item().IsRegex() {
+			c.always = append(c.always, i)
+			continue
+		}
+		for _, s := range c.samples[i] {
+			pattern := strings.ToLower(s.text)
+			k, ok := index[pattern]
+			if !ok {
+				k = len(patterns)
+				index[pattern] = k
+				patterns = append(patterns, pattern)
+				c.owners = append(c.owners, nil)
+			}
+			if n := len(c.owners[k]); n == 0 || c.owners[k][n-1] != i {
+				c.owners[k] = append(c.owners[k], i)
+			}
+		}
+	}
+	c.matcher = matcher.New(patterns)
+	return c
+}
+
+// candidates 返回可能在 text 中有匹配的规则序号，按升序排列
+func (c *issueChecker) candidates(text string) []int {
+	found := make([]bool, len(c.rules))
+	for _, i := range c.always {
+		found[i] = true
+	}
+	c.matcher.FindOverlapping(strings.ToLower(text), func(f matcher.Match) bool {
+		for _, i := range c.owners[f.Pattern] {
+			found[i] = true
+		}
+		return true
+	})
+
+	var indexes []int
+	for i, ok := range found {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// searchIssues 检查规则 j 能匹配的文本：被前面的规则完整匹配但替换结果不同，或其中一部分被其他规则匹配
+func (c *issueChecker) searchIssues(j int) []RuleIssue {
+	var issues []RuleIssue
+	reported := make(map[int]bool)
+	for _, s := range c.samples[j] {
+		if s.text == "" {
+			continue
+		}
+		for _, i := range c.candidates(s.text) {
+			if i == j || reported[i] {
+				continue
+			}
+			for _, m := range c.rules[i].findAll(s.text) {
+				if m.start > 0 || m.end < len(s.text) {
+					reported[i] = true
+					issues = append(issues, RuleIssue{
+						Kind:  IssueSubstring,
+						Rules: []int{i + 1, j + 1},
+						Message: fmt.Sprintf("替换项 #%d 的查找内容 '%s' 是替换项 #%d 的查找内容 '%s' 的一部分，替换结果取决于替换项的顺序",
+							i+1, s.text[m.start:m.end], j+1, s.text),
+					})
+					break
+				}
+				// 后面的规则完整匹配前面规则的文本时，这段文本总是由前面的规则替换，不影响结果
+				if i > j || m.replacement == s.replacement {
+					continue
+				}
+				reported[i] = true
+				issues = append(issues, RuleIssue{
+					Kind:  IssueDuplicate,
+					Rules: []int{i + 1, j + 1},
+					Message: fmt.Sprintf("替换项 #%d 与 #%d 查找相同的内容 '%s'，但分别替换为 '%s' 和 '%s'",
+						i+1, j+1, s.text, m.replacement, s.replacement),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// replaceIssues 检查规则 i 的替换内容中是否有其他规则（或自身）能匹配的内容
+func (c *issueChecker) replaceIssues(i int) []RuleIssue {
+	var issues []RuleIssue
+	reported := make(map[int]bool)
+	for _, s := range c.samples[i] {
+		if s.replacement == "" {
+			continue
+		}
+		for _, j := range c.candidates(s.replacement) {
+			if reported[j] {
+				continue
+			}
+			if matches := c.rules[j].findAll(s.replacement); len(matches) > 0 {
+				reported[j] = true
+				m := matches[0]
+				issues = append(issues, chainIssue(i, j, s.replacement, s.replacement[m.start:m.end]))
+			}
+		}
+	}
+	return issues
+}
+
+// chainIssue 生成替换项 i 的替换内容 replacement 中包含替换项 j 能匹配的内容 found 的问题
+func chainIssue(i, j int, replacement, found string) RuleIssue {
+	if i == j {
+		return RuleIssue{
+			Kind:  IssueNonIdempotent,
+			Rules: []int{i + 1},
+			Message: fmt.Sprintf("替换项 #%d 的替换内容 '%s' 包含其查找内容 '%s'，重复运行会再次替换",
+				i+1, replacement, found),
+		}
+	}
+
+	when := "重复运行时会被再次替换"
+	if j > i {
+		when = "按顺序替换时会在同一次运行中被再次替换"
+	}
+	return RuleIssue{
+		Kind:  IssueChain,
+		Rules: []int{i + 1, j + 1},
+		Message: fmt.Sprintf("替换项 #%d 的替换内容 '%s' 包含替换项 #%d 的查找内容 '%s'，%s",
+			i+1, replacement, j+1, found, when),
+	}
+}
@@ -0,0 +1,99 @@
+package replacer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/file-replacer/internal/config"
+)
+
+// issueKinds 返回问题的类型和涉及的替换项序号
+func issueKinds(issues []RuleIssue) []string {
+	var kinds []string
+	for _, issue := range issues {
+		kind := issue.Kind
+		for _, n := range issue.Rules {
+			kind += fmt.Sprintf(" #%d", n)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// TestRuleIssues 检查按各规则实际的匹配方式判断问题，whole_word、ignore_case 等规则不会误报
+func TestRuleIssues(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []config.ReplaceItem
+		want  []string
+	}{
+		{
+			name:  "字面规则替换内容包含查找内容",
+			items: []config.ReplaceItem{{SearchString: "id", ReplaceString: "ident"}},
+			want:  []string{"non-idempotent #1"},
+		},
+		{
+			name:  "完整单词规则的替换内容不构成完整单词",
+			items: []config.ReplaceItem{{SearchString: "id", ReplaceString: "ident", WholeWord: true}},
+		},
+		{
+			name: "完整单词规则不匹配更长单词的一部分",
+			items: []config.ReplaceItem{
+				{SearchString: "id", ReplaceString: "key", WholeWord: true},
+				{SearchString: "valid", ReplaceString: "ok"},
+			},
+		},
+		{
+			name: "不区分大小写的规则匹配另一种大小写的替换内容",
+			items: []config.ReplaceItem{
+				{SearchString: "old", ReplaceString: "NEW"},
+				{SearchString: "new", ReplaceString: "next", IgnoreCase: true},
+			},
+			want: []string{"chain #1 #2"},
+		},
+		{
+			name: "不区分大小写时查找内容相同",
+			items: []config.ReplaceItem{
+				{SearchString: "Foo", ReplaceString: "bar", IgnoreCase: true},
+				{SearchString: "FOO", ReplaceString: "QUX"},
+			},
+			want: []string{"duplicate #1 #2"},
+		},
+		{
+			name: "主机名规则不匹配更长主机名的一部分",
+			items: []config.ReplaceItem{
+				{SearchString: "example.com", ReplaceString: "example.org", Type: config.RuleHost},
+				{SearchString: "old.example.com", ReplaceString: "new.example.org", Type: config.RuleHost},
+			},
+		},
+		{
+			name: "查找内容是另一个查找内容的一部分",
+			items: []config.ReplaceItem{
+				{SearchString: "foo", ReplaceString: "x"},
+				{SearchString: "foobar", ReplaceString: "y"},
+			},
+			want: []string{"substring #1 #2"},
+		},
+		{
+			name: "标识符规则按各命名风格检查",
+			items: []config.ReplaceItem{
+				{SearchString: "user id", ReplaceString: "account id", Type: config.RuleIdentifier},
+				{SearchString: "accountId", ReplaceString: "accountKey"},
+			},
+			want: []string{"chain #1 #2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := RuleIssues(tt.items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := issueKinds(issues); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package replacer
+
+import (
+	"github.com/yourusername/file-replacer/internal/matcher"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// 连续的字面规则至少有这么多条时才尝试合并为一次扫描
+const minLiteralGroup = 2
+
+// step 替换的一个步骤：一条规则，或一组合并为一次扫描的字面规则
+type step struct {
+	// 第一条规则在规则列表中的序号
+	first int
+	rules []rule
+	// 合并扫描使用的自动机，单条规则时为 nil
+	matcher *matcher.Matcher
+}
+
+// planSteps 规划按顺序替换的执行步骤：连续的字面规则在互不影响时合并为一次 Aho-Corasick 扫描，
+// 结果与逐条替换完全相同。某条规则与前面的规则相互影响时，从该规则处拆开，两侧仍各自合并
+func planSteps(rules []rule) []step {
+	var steps []step
+	// 因相互影响而拆开的位置
+	var splits []int
+	for i := 0; i < len(rules); {
+		j := i
+		for j < len(rules) {
+			if _, ok := rules[j].(*literalRule); !ok {
+				break
+			}
+			j++
+		}
+		if j == i {
+			steps = append(steps, step{first: i, rules: rules[i : i+1]})
+			i++
+			continue
+		}
+
+		for i < j {
+			n, m := 1, (*matcher.Matcher)(nil)
+			if j-i >= minLiteralGroup {
+				n, m = longestIndependent(rules[i:j])
+			}
+			if m != nil {
+				steps = append(steps, step{first: i, rules: rules[i : i+n], matcher: m})
+				logger.Log.Debugf("替换项 #%d 至 #%d 将合并为一次扫描", i+1, i+n)
+			} else {
+				steps = append(steps, step{first: i, rules: rules[i : i+1]})
+			}
+			i += n
+			if i < j {
+				splits = append(splits, i)
+				logger.Log.Debugf("替换项 #%d 与前面的替换项相互影响，从该项起重新合并", i+1)
+			}
+		}
+	}
+
+	if len(splits) > 0 {
+		logger.Log.Infof("有 %d 个字面替换项与前面的替换项相互影响（如替换项 #%d 的查找内容出现在前面的替换内容中），"+
+			"连续的字面替换项在这些位置拆开，共 %d 个替换步骤", len(splits), splits[0]+1, len(steps))
+	}
+	return steps
+}
+
+// longestIndependent 返回从开头起能合并为一次扫描的最长规则数及其自动机，只有一条规则时自动机为 nil。
+// 能合并的规则组去掉末尾的规则后仍能合并，因此先倍增再二分查找，规则很多时也只需构建少量自动机
+func longestIndependent(rules []rule) (int, *matcher.Matcher) {
+	if m := independentLiterals(rules); m != nil {
+		return len(rules), m
+	}
+
+	// good 条规则能合并，bad 条不能
+	good, bad := 1, len(rules)
+	var best *matcher.Matcher
+	for size := minLiteralGroup; size < bad; size *= 2 {
+		m := independentLiterals(rules[:size])
+		if m == nil {
+			bad = size
+			break
+		}
+		good, best = size, m
+	}
+	for bad-good > 1 {
+		mid := (good + bad) / 2
+		if m := independentLiterals(rules[:mid]); m != nil {
+			good, best = mid, m
+		} else {
+			bad = mid
+		}
+	}
+	return good, best
+}
+
+// independentLiterals 判断一组字面规则能否合并为一次扫描，能时返回构建好的自动机，否则返回 nil。
+//
+// 逐条替换时，后面的规则在前面规则替换后的内容上查找；一次扫描则只在原始内容上按
+// “最靠前、最长”选取匹配。两者结果相同的充分条件是，对任意 i < j：
+//   - 替换内容 i 不会与周围内容一起组成查找内容 j（包括替换内容为空时两侧内容相接）
+//   - 查找内容 i 不是查找内容 j 的真子串
+//   - 查找内容 j 的真后缀不是查找内容 i 的前缀，即 j 的匹配不会抢在与之重叠的 i 的匹配之前
+func independentLiterals(rules []rule) *matcher.Matcher {
+	n := len(rules)
+	searches := make([]string, n)
+	replaces := make([]string, n)
+	reversed := make([]string, n)
+	// 每个查找内容最后出现的序号，重复的查找内容在自动机中只报告最小序号
+	last := make(map[string]int, n)
+	for i, r := range rules {
+		item := r.item()
+		searches[i] = item.SearchString
+		replaces[i] = item.ReplaceString
+		reversed[i] = reverse(item.SearchString)
+		last[item.SearchString] = i
+	}
+
+	forward := matcher.New(searches)
+	backward := matcher.New(reversed)
+	replacements := matcher.New(replaces)
+
+	for i := 0; i < n; i++ {
+		repl := replaces[i]
+		if repl == "" {
+			// 删除内容会使两侧内容相接，最后一条规则之后没有规则，不受影响
+			if i < n-1 {
+				return nil
+			}
+			continue
+		}
+
+		// 替换内容中包含后面规则的查找内容
+		chained := false
+		forward.FindOverlapping(repl, func(m matcher.Match) bool {
+			chained = last[searches[m.Pattern]] > i
+			return !chained
+		})
+		if chained {
+			return nil
+		}
+		// 替换内容的末尾与之后的内容组成后面规则的查找内容
+		if _, hi, ok := forward.SuffixPrefix(repl); ok && hi > i {
+			return nil
+		}
+		// 替换内容的开头与之前的内容组成后面规则的查找内容
+		if _, hi, ok := backward.SuffixPrefix(reverse(repl)); ok && hi > i {
+			return nil
+		}
+	}
+
+	for j := 0; j < n; j++ {
+		search := searches[j]
+		conflict := false
+
+		// 前面规则的替换内容位于查找内容 j 中间
+		replacements.FindOverlapping(search, func(m matcher.Match) bool {
+			conflict = m.Pattern < j
+			return !conflict
+		})
+		// 前面规则的查找内容是查找内容 j 的真子串
+		if !conflict {
+			forward.FindOverlapping(search, func(m matcher.Match) bool {
+				conflict = m.Pattern < j && m.End-m.Start < len(search)
+				return !conflict
+			})
+		}
+		if conflict {
+			return nil
+		}
+		// 查找内容 j 的真后缀是前面规则查找内容的前缀
+		if len(search) > 1 {
+			if lo, _, ok := forward.SuffixPrefix(search[1:]); ok && lo < j {
+				return nil
+			}
+		}
+	}
+
+	return forward
+}
+
+// reverse 按字节反转字符串
+func reverse(s string) string {
+	b := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		b[len(s)-1-i] = s[i]
+	}
+	return string(b)
+}
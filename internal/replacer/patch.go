@@ -0,0 +1,101 @@
+package replacer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/diff"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// patchCollector 在预览模式下收集每个文件的差异，结束时按路径顺序统一输出
+type patchCollector struct {
+	mu      sync.Mutex
+	context int
+	target  string
+	patches map[string]string
+}
+
+// newPatchCollector 根据配置创建差异收集器，未开启差异预览时返回 nil
+func newPatchCollector(cfg *config.Config) *patchCollector {
+	if !cfg.DryRun || (!cfg.Diff && cfg.PatchFile == "") {
+		return nil
+	}
+	return &patchCollector{
+		context: cfg.DiffContext,
+		target:  cfg.PatchFile,
+		patches: make(map[string]string),
+	}
+}
+
+// add 记录一个文件替换前后的差异
+func (p *patchCollector) add(filePath, oldContent, newContent string) {
+	if p == nil {
+		return
+	}
+
+	name := patchPath(filePath)
+	patch := diff.Unified(name, name, oldContent, newContent, p.context)
+	if patch == "" {
+		return
+	}
+
+	p.mu.Lock()
+	p.patches[name] = patch
+	p.mu.Unlock()
+}
+
+// flush 输出所有差异到补丁文件或标准输出
+func (p *patchCollector) flush() error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, 0, len(p.patches))
+	for name := range p.patches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out io.Writer = os.Stdout
+	if p.target != "" {
+		f, err := os.Create(p.target)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	for _, name := range names {
+		if _, err := io.WriteString(out, p.patches[name]); err != nil {
+			return err
+		}
+	}
+
+	if p.target != "" {
+		logger.Log.Infof("已将 %d 个文件的差异写入 %s", len(names), p.target)
+	}
+	return nil
+}
+
+// patchPath 返回补丁中使用的文件路径：尽量相对当前目录，并统一使用 / 分隔
+func patchPath(filePath string) string {
+	path := filePath
+	if filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
@@ -0,0 +1,361 @@
+package replacer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/failures"
+	"github.com/yourusername/file-replacer/internal/journal"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// ReplaceResult 单个文件的替换结果
+type ReplaceResult struct {
+	FilePath        string
+	Replaced        int
+	Error           error
+	ContentModified bool
+	// 是否因为是二进制文件而跳过
+	Skipped bool
+	// 每个替换项的匹配数，与 Config.ReplaceItems 一一对应，没有匹配时为 nil
+	RuleCounts []int
+	// 区分写法的替换项（如标识符规则）中各写法的匹配数，键为替换项下标，没有时为 nil
+	VariantCounts map[int]map[string]int
+}
+
+// Summary 一次替换操作的汇总
+type Summary struct {
+	// 处理的文件总数
+	Files int
+	// 有匹配的文件数
+	Matched int
+	// 内容被修改的文件数（预览模式下为将被修改的文件数）
+	Modified int
+	// 替换总数
+	Replaced int
+	// 跳过的二进制文件数
+	Skipped int
+	// 处理出错的文件数
+	Failed int
+	// 每个替换项在所有文件中的匹配总数
+	RuleCounts []int
+	// 区分写法的替换项在所有文件中各写法的匹配总数，键为替换项下标
+	VariantCounts map[int]map[string]int
+	// 有匹配、被跳过或出错的文件的结果，按完成顺序排列
+	Results []ReplaceResult
+	// 是否被取消（如收到 Ctrl-C）而提前结束
+	Interrupted bool
+	// 已扫描但因取消或 fail-fast 而未处理的文件，扫描与替换同时进行时不含尚未扫描到的文件
+	Unprocessed []string
+	// 扫描多个根目录时每个根目录的汇总，与配置中的根目录一一对应；只有一个根目录时为 nil
+	Roots []RootSummary
+	// 开始时间和耗时
+	Started  time.Time
+	Duration time.Duration
+
+	// 各根目录的绝对路径，用于判断文件所属的根目录
+	rootPaths []string
+	mu        sync.Mutex
+}
+
+// RootSummary 单个根目录下文件的汇总
+type RootSummary struct {
+	Root     string
+	Files    int
+	Matched  int
+	Modified int
+	Replaced int
+	Skipped  int
+	Failed   int
+}
+
+// add 记录根目录下一个文件的结果
+func (r *RootSummary) add(result ReplaceResult) {
+	r.Files++
+	switch {
+	case result.Error != nil:
+		r.Failed++
+	case result.Skipped:
+		r.Skipped++
+	case result.Replaced > 0:
+		r.Matched++
+		r.Replaced += result.Replaced
+		if result.ContentModified {
+			r.Modified++
+		}
+	}
+}
+
+// newSummary 创建汇总，有多个根目录时同时按根目录汇总
+func newSummary(rules int, roots []string) *Summary {
+	s := &Summary{
+		RuleCounts:    make([]int, rules),
+		VariantCounts: make(map[int]map[string]int),
+		Started:       time.Now(),
+	}
+	if len(roots) > 1 {
+		for _, root := range roots {
+			abs, err := filepath.Abs(root)
+			if err != nil {
+				abs = root
+			}
+			s.Roots = append(s.Roots, RootSummary{Root: root})
+			s.rootPaths = append(s.rootPaths, abs)
+		}
+	}
+	return s
+}
+
+// rootOf 返回文件所属根目录的下标，不在任何根目录下（如 -files-from 列出的其他文件）时返回 -1
+func (s *Summary) rootOf(filePath string) int {
+	if len(s.rootPaths) == 0 {
+		return -1
+	}
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return -1
+	}
+	for i, root := range s.rootPaths {
+		if abs == root || strings.HasPrefix(abs, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// add 记录一个文件的结果，可并发调用
+func (s *Summary) add(result ReplaceResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Files++
+	if i := s.rootOf(result.FilePath); i >= 0 {
+		s.Roots[i].add(result)
+	}
+	switch {
+	case result.Error != nil:
+		s.Failed++
+	case result.Skipped:
+		s.Skipped++
+	case result.Replaced > 0:
+		s.Matched++
+		s.Replaced += result.Replaced
+		if result.ContentModified {
+			s.Modified++
+		}
+		for i, count := range result.RuleCounts {
+			s.RuleCounts[i] += count
+		}
+		for i, variants := range result.VariantCounts {
+			if s.VariantCounts[i] == nil {
+				s.VariantCounts[i] = make(map[string]int)
+			}
+			for name, count := range variants {
+				s.VariantCounts[i][name] += count
+			}
+		}
+	default:
+		// 没有匹配的文件只计数，不保留结果
+		return
+	}
+	s.Results = append(s.Results, result)
+}
+
+// skip 记录一个未处理的文件，可并发调用
+func (s *Summary) skip(filePath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Unprocessed = append(s.Unprocessed, filePath)
+}
+
+// finish 记录耗时并输出汇总日志
+func (s *Summary) finish(ctx context.Context) {
+	s.Duration = time.Since(s.Started)
+	s.Interrupted = ctx.Err() != nil
+	if s.Interrupted {
+		logger.Log.Warnf("操作已中断，已检查 %d 个文件，已扫描的文件中有 %d 个未处理", s.Files, len(s.Unprocessed))
+	}
+	logger.Log.Infof("替换完成，共处理 %d 个文件，替换 %d 处内容，跳过 %d 个二进制文件",
+		s.Matched, s.Replaced, s.Skipped)
+	for _, root := range s.Roots {
+		logger.Log.Infof("根目录 %s: 检查 %d 个文件，处理 %d 个文件，替换 %d 处内容，跳过 %d 个二进制文件，%d 个文件失败",
+			root.Root, root.Files, root.Matched, root.Replaced, root.Skipped, root.Failed)
+	}
+	for _, i := range sortedKeys(s.VariantCounts) {
+		variants := s.VariantCounts[i]
+		names := make([]string, 0, len(variants))
+		for name := range variants {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, len(names))
+		for k, name := range names {
+			parts[k] = fmt.Sprintf("%s %d", name, variants[name])
+		}
+		logger.Log.Infof("替换项 #%d 各写法的匹配数: %s", i+1, strings.Join(parts, "，"))
+	}
+	if s.Failed > 0 {
+		logger.Log.Warnf("%d 个文件处理失败", s.Failed)
+	}
+}
+
+// sortedKeys 返回升序排列的键
+func sortedKeys(m map[int]map[string]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// fileProcessor 两种替换器共用的单文件处理逻辑
+type fileProcessor struct {
+	config   *config.Config
+	rules    *ruleSet
+	patches  *patchCollector
+	journal  *journal.Journal
+	failures failures.List
+	// fail-fast 策略下出错后置为 1，不再处理剩余文件
+	stop int32
+}
+
+// newFileProcessor 编译替换规则，并准备差异收集和运行记录
+func newFileProcessor(cfg *config.Config) (*fileProcessor, error) {
+	if len(cfg.ReplaceItems) == 0 {
+		return nil, fmt.Errorf("没有指定替换项")
+	}
+
+	rules, err := compileRules(cfg.ReplaceItems)
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := openJournal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	logRules(cfg.ReplaceItems)
+
+	if cfg.DryRun {
+		logger.Log.Info("当前为预览模式，不会进行实际替换")
+	}
+
+	return &fileProcessor{
+		config:  cfg,
+		rules:   newRuleSet(rules, cfg.Mode),
+		patches: newPatchCollector(cfg),
+		journal: j,
+	}, nil
+}
+
+// close 结束运行记录，在预览模式下输出差异，并返回本次替换的错误：
+// 被取消时返回取消原因，否则按出错处理策略决定
+func (p *fileProcessor) close(ctx context.Context) error {
+	closeJournal(p.journal)
+	if err := p.patches.flush(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("替换已中断: %w", err)
+	}
+
+	switch p.config.OnError {
+	case config.OnErrorFailFast:
+		if first := p.failures.First(); first != nil {
+			return fmt.Errorf("处理文件 %s 失败，已停止处理剩余文件: %v", first.Path, first.Err)
+		}
+	case config.OnErrorCollect:
+		return p.failures.Err()
+	}
+	return nil
+}
+
+// stopped 判断是否应停止处理剩余文件：已被取消，或已因 fail-fast 策略停止
+func (p *fileProcessor) stopped(ctx context.Context) bool {
+	return ctx.Err() != nil || atomic.LoadInt32(&p.stop) == 1
+}
+
+// process 处理单个文件，并按出错处理策略记录错误
+func (p *fileProcessor) process(filePath string) ReplaceResult {
+	result := p.processFile(filePath)
+	if result.Error != nil {
+		p.failures.Add(filePath, result.Error)
+		if p.config.OnError == config.OnErrorFailFast {
+			atomic.StoreInt32(&p.stop, 1)
+		}
+	}
+	return result
+}
+
+// processFile 读取、替换并写回单个文件
+func (p *fileProcessor) processFile(filePath string) ReplaceResult {
+	result := ReplaceResult{
+		FilePath: filePath,
+	}
+
+	// 读取文件内容
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+
+	// 默认跳过二进制文件，避免损坏图片、字体等
+	if !p.config.Binary && isBinary(content) {
+		result.Skipped = true
+		logger.Log.Infof("跳过二进制文件: %s", filePath)
+		return result
+	}
+
+	// 文件内容之后不会再被修改，直接作为字符串使用，不复制
+	originalContent := bytesToString(content)
+
+	// 依次应用每个替换规则
+	contentStr, counts := p.rules.apply(filePath, originalContent)
+	result.Replaced = counts.total
+	result.RuleCounts = counts.rules
+	result.VariantCounts = counts.variants
+
+	// 如果有替换
+	if result.Replaced > 0 {
+		result.ContentModified = contentStr != originalContent
+
+		// 预览模式下记录差异
+		if p.config.DryRun {
+			p.patches.add(filePath, originalContent, contentStr)
+		}
+
+		// 如果不是预览模式且内容有变化，则写入文件
+		if !p.config.DryRun && result.ContentModified {
+			err = writeFile(p.config, p.journal, filePath, content, contentStr)
+			if err != nil {
+				result.Error = err
+				return result
+			}
+			logger.Log.Infof("已更新文件 %s，共替换 %d 处内容", filePath, result.Replaced)
+		}
+	}
+
+	return result
+}
+
+// bytesToString 不复制地将字节切片转换为字符串，调用方之后不能再修改 b
+func bytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// stringToBytes 不复制地将字符串转换为字节切片，返回的切片只能读取
+func stringToBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
@@ -0,0 +1,246 @@
+package replacer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// match 表示内容中的一处匹配
+type match struct {
+	start       int
+	end         int
+	replacement string
+	// 匹配到的写法名称，只用于区分写法的规则（如标识符规则的 camel、snake）
+	variant string
+}
+
+// rule 编译后的替换规则
+type rule interface {
+	// findAll 返回内容中所有互不重叠的匹配，按位置升序排列
+	findAll(content string) []match
+	// item 返回规则对应的配置项
+	item() config.ReplaceItem
+	// samples 返回规则能匹配的各种写法及其替换结果，用于检查替换项之间的问题
+	samples() []sample
+}
+
+// sample 规则能完整匹配的一段文本及其替换结果
+type sample struct {
+	// 匹配的文本，无法列举时（如一般的正则表达式）为空
+	text        string
+	replacement string
+}
+
+// literalRule 字面字符串规则
+type literalRule struct {
+	cfg config.ReplaceItem
+}
+
+func (r *literalRule) item() config.ReplaceItem {
+	return r.cfg
+}
+
+func (r *literalRule) samples() []sample {
+	return []sample{{text: r.cfg.SearchString, replacement: r.cfg.ReplaceString}}
+}
+
+func (r *literalRule) findAll(content string) []match {
+	var matches []match
+	search := r.cfg.SearchString
+	offset := 0
+	for {
+		idx := strings.Index(content[offset:], search)
+		if idx < 0 {
+			break
+		}
+		start := offset + idx
+		matches = append(matches, match{
+			start:       start,
+			end:         start + len(search),
+			replacement: r.cfg.ReplaceString,
+		})
+		offset = start + len(search)
+	}
+	return matches
+}
+
+// regexRule 正则表达式规则
+type regexRule struct {
+	cfg config.ReplaceItem
+	re  *regexp.Regexp
+}
+
+func (r *regexRule) item() config.ReplaceItem {
+	return r.cfg
+}
+
+// samples 只有表达式是纯字面内容时才能列举匹配的文本，替换内容按原样返回，不展开捕获组引用
+func (r *regexRule) samples() []sample {
+	text, complete := r.re.LiteralPrefix()
+	if !complete {
+		text = ""
+	}
+	return []sample{{text: text, replacement: r.cfg.ReplaceString}}
+}
+
+func (r *regexRule) findAll(content string) []match {
+	locs := r.re.FindAllStringSubmatchIndex(content, -1)
+	if len(locs) == 0 {
+		return nil
+	}
+
+	matches := make([]match, 0, len(locs))
+	for _, loc := range locs {
+		// 展开 $1、${name} 等捕获组引用
+		replacement := r.re.ExpandString(nil, r.cfg.ReplaceString, content, loc)
+		matches = append(matches, match{
+			start:       loc[0],
+			end:         loc[1],
+			replacement: string(replacement),
+		})
+	}
+	return matches
+}
+
+// compileRules 将配置中的替换项编译为规则
+func compileRules(items []config.ReplaceItem) ([]rule, error) {
+	rules := make([]rule, 0, len(items))
+	for i, item := range items {
+		if item.SearchString == "" {
+			return nil, fmt.Errorf("替换项 #%d 的查找内容为空", i+1)
+		}
+
+		if !item.IsHost() && (item.EscapedDots || item.Scheme != "" || item.Port != "") {
+			return nil, fmt.Errorf("替换项 #%d: escaped_dots、scheme 和 port 只能用于主机名规则", i+1)
+		}
+
+		if len(item.Variants) > 0 && item.Type != "" && item.Type != config.RuleLiteral {
+			return nil, fmt.Errorf("替换项 #%d: variants 只能用于字面规则", i+1)
+		}
+		if item.IgnoreCase && (item.Type != "" && item.Type != config.RuleLiteral || len(item.Variants) > 0) {
+			return nil, fmt.Errorf("替换项 #%d: ignore_case 只能用于字面规则，且不能与 variants 同时使用", i+1)
+		}
+
+		switch item.Type {
+		case "", config.RuleLiteral:
+			if item.IgnoreCase {
+				r, err := newCaseRule(item)
+				if err != nil {
+					return nil, fmt.Errorf("替换项 #%d 无效: %v", i+1, err)
+				}
+				rules = append(rules, r)
+				continue
+			}
+			if len(item.Variants) == 0 {
+				rules = append(rules, &literalRule{cfg: item})
+				continue
+			}
+			r, err := newVariantRule(item)
+			if err != nil {
+				return nil, fmt.Errorf("替换项 #%d 的%v", i+1, err)
+			}
+			rules = append(rules, r)
+		case config.RuleRegex:
+			re, err := regexp.Compile(item.SearchString)
+			if err != nil {
+				return nil, fmt.Errorf("替换项 #%d 的正则表达式无效: %v", i+1, err)
+			}
+			rules = append(rules, &regexRule{cfg: item, re: re})
+		case config.RuleIdentifier:
+			r, err := newIdentifierRule(item)
+			if err != nil {
+				return nil, fmt.Errorf("替换项 #%d 的%v", i+1, err)
+			}
+			rules = append(rules, r)
+		case config.RuleHost:
+			if err := validateHostItem(item); err != nil {
+				return nil, fmt.Errorf("替换项 #%d 的%v", i+1, err)
+			}
+			r, err := newHostRule(item)
+			if err != nil {
+				return nil, fmt.Errorf("替换项 #%d 的主机名无效: %v", i+1, err)
+			}
+			rules = append(rules, r)
+		default:
+			return nil, fmt.Errorf("替换项 #%d 的类型 '%s' 不受支持", i+1, item.Type)
+		}
+	}
+
+	// 只匹配完整单词的替换项，在规则的匹配结果上检查单词边界
+	for i, item := range items {
+		if !item.WholeWord {
+			if item.TokenChars != "" {
+				return nil, fmt.Errorf("替换项 #%d: token_chars 只能与 whole_word 一起使用", i+1)
+			}
+			continue
+		}
+		token, err := newTokenClass(item.TokenChars)
+		if err != nil {
+			return nil, fmt.Errorf("替换项 #%d 的%v", i+1, err)
+		}
+		rules[i] = &wordRule{rule: rules[i], token: token}
+	}
+	return rules, nil
+}
+
+// applyMatches 按匹配结果生成替换后的内容
+func applyMatches(content string, matches []match) string {
+	var b strings.Builder
+	b.Grow(len(content))
+	last := 0
+	for _, m := range matches {
+		b.WriteString(content[last:m.start])
+		b.WriteString(m.replacement)
+		last = m.end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// logMatches 输出单个文件中一条规则的匹配数
+func logMatches(filePath string, r rule, count int) {
+	logger.Log.Infof("文件 %s: 找到 '%s' %d 处匹配", filePath, r.item().SearchString, count)
+}
+
+// logRules 输出替换项列表
+func logRules(items []config.ReplaceItem) {
+	logger.Log.Infof("开始替换操作，共有 %d 个替换项", len(items))
+	for i, item := range items {
+		if item.Type == config.RuleIdentifier {
+			logger.Log.Infof("替换项 #%d: 标识符 '%s' 替换为 '%s'，包括各种命名风格",
+				i+1, item.SearchString, item.ReplaceString)
+			continue
+		}
+		if item.IsHost() {
+			logger.Log.Infof("替换项 #%d: 主机名 '%s' 替换为 '%s'",
+				i+1, item.SearchString, item.ReplaceString)
+			continue
+		}
+		if item.IsRegex() {
+			logger.Log.Infof("替换项 #%d: 正则 '%s' 替换为 '%s'",
+				i+1, item.SearchString, item.ReplaceString)
+			continue
+		}
+		if item.IgnoreCase {
+			logger.Log.Infof("替换项 #%d: 搜索 '%s' 替换为 '%s'，不区分大小写并保留大小写形式",
+				i+1, item.SearchString, item.ReplaceString)
+			continue
+		}
+		if len(item.Variants) > 0 {
+			logger.Log.Infof("替换项 #%d: 搜索 '%s' 替换为 '%s'，包括 %s 编码写法",
+				i+1, item.SearchString, item.ReplaceString, strings.Join(item.Variants, "、"))
+			continue
+		}
+		if item.WholeWord {
+			logger.Log.Infof("替换项 #%d: 搜索完整单词 '%s' 替换为 '%s'",
+				i+1, item.SearchString, item.ReplaceString)
+			continue
+		}
+		logger.Log.Infof("替换项 #%d: 搜索 '%s' 替换为 '%s'",
+			i+1, item.SearchString, item.ReplaceString)
+	}
+}
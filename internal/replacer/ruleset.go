@@ -0,0 +1,200 @@
+package replacer
+
+import (
+	"sort"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/matcher"
+)
+
+// ruleSet 编译后的规则，以及按替换方式准备好的执行计划
+type ruleSet struct {
+	rules        []rule
+	simultaneous bool
+
+	// 按顺序替换时的执行步骤
+	steps []step
+
+	// 同时替换时，所有字面规则共用的自动机，以及自动机中模式序号对应的规则序号
+	literals     *matcher.Matcher
+	literalRules []int
+	// 同时替换时不能由自动机匹配的规则（正则、主机名）序号
+	otherRules []int
+}
+
+// ruleMatch 同时替换时带规则序号的匹配
+type ruleMatch struct {
+	match
+	rule int
+}
+
+// newRuleSet 按替换方式准备规则的执行计划
+func newRuleSet(rules []rule, mode string) *ruleSet {
+	set := &ruleSet{rules: rules}
+	if mode != config.ModeSimultaneous {
+		set.steps = planSteps(rules)
+		return set
+	}
+
+	set.simultaneous = true
+	var patterns []string
+	for i, r := range rules {
+		if _, ok := r.(*literalRule); ok {
+			patterns = append(patterns, r.item().SearchString)
+			set.literalRules = append(set.literalRules, i)
+			continue
+		}
+		set.otherRules = append(set.otherRules, i)
+	}
+	set.literals = matcher.New(patterns)
+	return set
+}
+
+// matchCounts 单个文件中各规则的匹配数
+type matchCounts struct {
+	// 匹配总数
+	total int
+	// 每条规则的匹配数，没有任何匹配时为 nil
+	rules []int
+	// 区分写法的规则（如标识符规则）中各写法的匹配数，键为规则下标，没有时为 nil
+	variants map[int]map[string]int
+}
+
+// add 记录规则 rule 的一处匹配
+func (c *matchCounts) add(rules, rule int, m match) {
+	if c.rules == nil {
+		c.rules = make([]int, rules)
+	}
+	c.rules[rule]++
+	c.total++
+	if m.variant == "" {
+		return
+	}
+	if c.variants == nil {
+		c.variants = make(map[int]map[string]int)
+	}
+	if c.variants[rule] == nil {
+		c.variants[rule] = make(map[string]int)
+	}
+	c.variants[rule][m.variant]++
+}
+
+// apply 对内容应用所有规则，返回替换后的内容和各规则的匹配数
+func (s *ruleSet) apply(filePath, content string) (string, *matchCounts) {
+	counts := &matchCounts{}
+	if s.simultaneous {
+		content = s.applySimultaneous(content, counts)
+	} else {
+		content = s.applySequential(content, counts)
+	}
+
+	for i, count := range counts.rules {
+		if count > 0 {
+			logMatches(filePath, s.rules[i], count)
+		}
+	}
+	return content, counts
+}
+
+// applySequential 依次执行各步骤，后面的规则在前面规则替换后的内容上查找
+func (s *ruleSet) applySequential(content string, counts *matchCounts) string {
+	for _, st := range s.steps {
+		if st.matcher == nil {
+			matches := st.rules[0].findAll(content)
+			if len(matches) == 0 {
+				continue
+			}
+			content = applyMatches(content, matches)
+			for _, m := range matches {
+				counts.add(len(s.rules), st.first, m)
+			}
+			continue
+		}
+
+		found := st.matcher.FindAll(content)
+		if len(found) == 0 {
+			continue
+		}
+		matches := make([]match, len(found))
+		for k, f := range found {
+			matches[k] = match{start: f.Start, end: f.End, replacement: st.rules[f.Pattern].item().ReplaceString}
+			counts.add(len(s.rules), st.first+f.Pattern, matches[k])
+		}
+		content = applyMatches(content, matches)
+	}
+	return content
+}
+
+// applySimultaneous 所有规则同时在原始内容上查找，替换结果不会再被其他规则处理。
+// 重叠的匹配中起始位置靠前的优先，其次是更长的，最后是排在前面的规则
+func (s *ruleSet) applySimultaneous(content string, counts *matchCounts) string {
+	var chosen []ruleMatch
+	if len(s.otherRules) == 0 {
+		// 只有字面规则时，自动机直接按相同的优先级选取匹配
+		for _, f := range s.literals.FindAll(content) {
+			chosen = append(chosen, s.literalMatch(f))
+		}
+	} else {
+		chosen = s.selectMatches(content)
+	}
+	if len(chosen) == 0 {
+		return content
+	}
+
+	matches := make([]match, len(chosen))
+	for k, m := range chosen {
+		matches[k] = m.match
+		counts.add(len(s.rules), m.rule, m.match)
+	}
+	return applyMatches(content, matches)
+}
+
+// selectMatches 收集字面规则的所有匹配（包括互相重叠的）和其他规则的匹配，
+// 再从左到右按优先级选取互不重叠的匹配
+func (s *ruleSet) selectMatches(content string) []ruleMatch {
+	var candidates []ruleMatch
+	s.literals.FindOverlapping(content, func(f matcher.Match) bool {
+		candidates = append(candidates, s.literalMatch(f))
+		return true
+	})
+	for _, i := range s.otherRules {
+		for _, m := range s.rules[i].findAll(content) {
+			candidates = append(candidates, ruleMatch{match: m, rule: i})
+		}
+	}
+
+	sort.Slice(candidates, func(a, b int) bool {
+		x, y := candidates[a], candidates[b]
+		if x.start != y.start {
+			return x.start < y.start
+		}
+		if x.end != y.end {
+			return x.end > y.end
+		}
+		return x.rule < y.rule
+	})
+
+	var chosen []ruleMatch
+	lastEnd := 0
+	for _, c := range candidates {
+		if c.start < lastEnd {
+			continue
+		}
+		// 空匹配不能与前一个匹配在同一位置重复选取
+		if c.start == c.end && len(chosen) > 0 && chosen[len(chosen)-1].end == c.start {
+			continue
+		}
+		chosen = append(chosen, c)
+		lastEnd = c.end
+	}
+	return chosen
+}
+
+// literalMatch 将自动机的匹配转换为带规则序号的匹配
+func (s *ruleSet) literalMatch(f matcher.Match) ruleMatch {
+	i := s.literalRules[f.Pattern]
+	return ruleMatch{
+		match: match{start: f.Start, end: f.End, replacement: s.rules[i].item().ReplaceString},
+		rule:  i,
+	}
+}
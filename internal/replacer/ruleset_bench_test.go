@@ -0,0 +1,132 @@
+package replacer
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/file-replacer/internal/config"
+)
+
+// hostItems 生成 n 个互不影响的主机名替换项
+func hostItems(n int) []config.ReplaceItem {
+	items := make([]config.ReplaceItem, n)
+	for i := range items {
+		items[i] = config.ReplaceItem{
+			SearchString:  fmt.Sprintf("app%04d.cmicrwx.cn", i),
+			ReplaceString: fmt.Sprintf("app%04d.cmicvip.cn", i),
+		}
+	}
+	return items
+}
+
+// benchContent 生成约 200KB 的内容，其中每行有一定概率包含某个替换项的查找内容
+func benchContent(items []config.ReplaceItem) string {
+	rng := rand.New(rand.NewSource(1))
+	var b strings.Builder
+	for b.Len() < 200*1024 {
+		b.WriteString(`<script src="https://`)
+		if rng.Intn(4) == 0 {
+			b.WriteString(items[rng.Intn(len(items))].SearchString)
+		} else {
+			b.WriteString("static.example.com")
+		}
+		b.WriteString("/js/app.js\"></script>\n")
+	}
+	return b.String()
+}
+
+// replaceLoop 原先的替换方式：逐条用 Contains、Count 和 ReplaceAll 处理
+func replaceLoop(items []config.ReplaceItem, content string) (string, int) {
+	total := 0
+	for _, item := range items {
+		if strings.Contains(content, item.SearchString) {
+			total += strings.Count(content, item.SearchString)
+			content = strings.ReplaceAll(content, item.SearchString, item.ReplaceString)
+		}
+	}
+	return content, total
+}
+
+func benchmarkRuleSet(b *testing.B, items []config.ReplaceItem) {
+	rules, err := compileRules(items)
+	if err != nil {
+		b.Fatal(err)
+	}
+	set := newRuleSet(rules, config.ModeSequential)
+	content := benchContent(items)
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set.apply("bench", content)
+	}
+}
+
+// BenchmarkReplaceLoop 原先逐条 Contains/Count/ReplaceAll 的方式，2000 个替换项
+func BenchmarkReplaceLoop(b *testing.B) {
+	items := hostItems(2000)
+	content := benchContent(items)
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		replaceLoop(items, content)
+	}
+}
+
+// BenchmarkRuleSetSequential 2000 个互不影响的替换项合并为一次扫描
+func BenchmarkRuleSetSequential(b *testing.B) {
+	benchmarkRuleSet(b, hostItems(2000))
+}
+
+// BenchmarkRuleSetConflict 其中一个替换项的替换内容包含后面的查找内容，拆为两次扫描
+func BenchmarkRuleSetConflict(b *testing.B) {
+	items := hostItems(2000)
+	items[999].ReplaceString = items[1499].SearchString
+	benchmarkRuleSet(b, items)
+}
+
+// BenchmarkPlanSteps 规划 2000 个替换项的执行步骤（构建自动机）的开销
+func BenchmarkPlanSteps(b *testing.B) {
+	rules, err := compileRules(hostItems(2000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		planSteps(rules)
+	}
+}
+
+// BenchmarkPlanStepsConflict 有冲突时查找拆分位置的开销
+func BenchmarkPlanStepsConflict(b *testing.B) {
+	items := hostItems(2000)
+	items[999].ReplaceString = items[1499].SearchString
+	rules, err := compileRules(items)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		planSteps(rules)
+	}
+}
+
+// BenchmarkMatcherFindAll 只测自动机查找 2000 个模式的开销
+func BenchmarkMatcherFindAll(b *testing.B) {
+	items := hostItems(2000)
+	rules, err := compileRules(items)
+	if err != nil {
+		b.Fatal(err)
+	}
+	m := independentLiterals(rules)
+	if m == nil {
+		b.Fatal("替换项应能合并")
+	}
+	content := benchContent(items)
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.FindAll(content)
+	}
+}
@@ -0,0 +1,119 @@
+package replacer
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// 每处匹配都会输出日志，测试和基准测试中不输出
+	logger.Log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// applyOneByOne 逐条应用规则，作为合并扫描的参照结果
+func applyOneByOne(rules []rule, content string) (string, []int) {
+	counts := make([]int, len(rules))
+	for i, r := range rules {
+		matches := r.findAll(content)
+		counts[i] = len(matches)
+		if len(matches) > 0 {
+			content = applyMatches(content, matches)
+		}
+	}
+	return content, counts
+}
+
+// randomString 生成由 alphabet 中字符组成、长度在 [min, max] 之间的字符串
+func randomString(rng *rand.Rand, alphabet string, min, max int) string {
+	b := make([]byte, min+rng.Intn(max-min+1))
+	for i := range b {
+		b[i] = alphabet[rng.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// TestSequentialMatchesOneByOne 随机生成字面规则和内容，检查按顺序替换时合并扫描的结果和各规则的匹配数
+// 与逐条替换完全相同，即 independentLiterals 的充分条件成立
+func TestSequentialMatchesOneByOne(t *testing.T) {
+	cases := 200000
+	if testing.Short() {
+		cases = 20000
+	}
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < cases; n++ {
+		items := make([]config.ReplaceItem, 1+rng.Intn(6))
+		for i := range items {
+			items[i] = config.ReplaceItem{
+				SearchString:  randomString(rng, "abc", 1, 3),
+				ReplaceString: randomString(rng, "abc", 0, 3),
+			}
+		}
+		rules, err := compileRules(items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := randomString(rng, "abc", 0, 24)
+
+		want, wantCounts := applyOneByOne(rules, content)
+		got, counts := newRuleSet(rules, config.ModeSequential).apply("test", content)
+		if got != want {
+			t.Fatalf("规则 %+v 应用于 %q: 得到 %q，逐条替换为 %q", items, content, got, want)
+		}
+		for i, count := range wantCounts {
+			gotCount := 0
+			if counts.rules != nil {
+				gotCount = counts.rules[i]
+			}
+			if gotCount != count {
+				t.Fatalf("规则 %+v 应用于 %q: 替换项 #%d 匹配 %d 处，逐条替换为 %d 处", items, content, i+1, gotCount, count)
+			}
+		}
+	}
+}
+
+// TestPlanStepsSplitsAtConflict 一条规则与后面的规则相互影响时，只在该处拆开，两侧仍各自合并
+func TestPlanStepsSplitsAtConflict(t *testing.T) {
+	items := hostItems(2000)
+	// 替换项 #1000 的替换内容包含替换项 #1500 的查找内容
+	items[999].ReplaceString = items[1499].SearchString
+	rules, err := compileRules(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := planSteps(rules)
+	if len(steps) != 2 {
+		t.Fatalf("应拆分为 2 个步骤，得到 %d 个", len(steps))
+	}
+	if steps[0].first != 0 || len(steps[0].rules) != 1499 || steps[0].matcher == nil {
+		t.Errorf("第一个步骤应合并替换项 #1 至 #1499，得到从 #%d 起的 %d 条", steps[0].first+1, len(steps[0].rules))
+	}
+	if steps[1].first != 1499 || len(steps[1].rules) != 501 || steps[1].matcher == nil {
+		t.Errorf("第二个步骤应合并替换项 #1500 至 #2000，得到从 #%d 起的 %d 条", steps[1].first+1, len(steps[1].rules))
+	}
+}
+
+// TestPlanStepsChain 依次连锁的规则不能合并，逐条替换
+func TestPlanStepsChain(t *testing.T) {
+	rules, err := compileRules([]config.ReplaceItem{
+		{SearchString: "a", ReplaceString: "b"},
+		{SearchString: "b", ReplaceString: "c"},
+		{SearchString: "c", ReplaceString: "d"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps := planSteps(rules); len(steps) != 3 {
+		t.Fatalf("应逐条替换，得到 %d 个步骤", len(steps))
+	}
+	got, _ := newRuleSet(rules, config.ModeSequential).apply("test", "abc")
+	if got != "ddd" {
+		t.Fatalf("得到 %q，应为 %q", got, "ddd")
+	}
+}
@@ -0,0 +1,201 @@
+package replacer
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/matcher"
+)
+
+// variantEncodings 每种编码写法对应的编码函数：百分号编码同时匹配大写和小写的十六进制，
+// HTML 写法同时匹配 . / : 的实体形式，正则写法同时匹配 JS 正则字面量中转义的斜杠，
+// 以及 new RegExp("...") 字符串中的双重转义
+var variantEncodings = map[string][]func(string) string{
+	config.VariantJSON:  {jsonEscape},
+	config.VariantURL:   {url.QueryEscape, lowerPercent(url.QueryEscape)},
+	config.VariantHTML:  htmlEncodings(),
+	config.VariantRegex: {regexp.QuoteMeta, jsRegexEscape, func(s string) string { return jsonEscape(regexp.QuoteMeta(s)) }},
+}
+
+// allVariants variants 为 all 时展开的编码写法，按此顺序生成
+var allVariants = []string{config.VariantJSON, config.VariantURL, config.VariantHTML, config.VariantRegex}
+
+// variantRule 同时匹配查找内容各种编码写法的字面规则，匹配到哪种写法就用同样的写法替换
+type variantRule struct {
+	cfg config.ReplaceItem
+	// 各写法的名称、查找内容和替换内容，第一项为原始写法
+	names        []string
+	searches     []string
+	replacements []string
+	matcher      *matcher.Matcher
+}
+
+// newVariantRule 按 Variants 展开字面规则的各种编码写法，相同的写法只保留一次
+func newVariantRule(item config.ReplaceItem) (*variantRule, error) {
+	names, err := expandVariants(item.Variants)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &variantRule{cfg: item}
+	seen := make(map[string]bool)
+	add := func(name, search, replace string) {
+		if search == "" || seen[search] {
+			return
+		}
+		seen[search] = true
+		r.names = append(r.names, name)
+		r.searches = append(r.searches, search)
+		r.replacements = append(r.replacements, replace)
+	}
+
+	add(config.RuleLiteral, item.SearchString, item.ReplaceString)
+	for _, name := range names {
+		for _, encode := range variantEncodings[name] {
+			add(name, encode(item.SearchString), encode(item.ReplaceString))
+		}
+	}
+	r.matcher = matcher.New(r.searches)
+	return r, nil
+}
+
+// expandVariants 校验编码写法名称，并展开 all
+func expandVariants(variants []string) ([]string, error) {
+	var names []string
+	for _, v := range variants {
+		switch {
+		case v == config.VariantAll:
+			names = append(names, allVariants...)
+		case variantEncodings[v] != nil:
+			names = append(names, v)
+		default:
+			return nil, fmt.Errorf("编码写法 '%s' 不受支持（可选 json、url、html、regex、all）", v)
+		}
+	}
+	return names, nil
+}
+
+func (r *variantRule) item() config.ReplaceItem {
+	return r.cfg
+}
+
+func (r *variantRule) samples() []sample {
+	samples := make([]sample, len(r.searches))
+	for i, search := range r.searches {
+		samples[i] = sample{text: search, replacement: r.replacements[i]}
+	}
+	return samples
+}
+
+func (r *variantRule) findAll(content string) []match {
+	found := r.matcher.FindAll(content)
+	if len(found) == 0 {
+		return nil
+	}
+	matches := make([]match, len(found))
+	for i, f := range found {
+		matches[i] = match{
+			start:       f.Start,
+			end:         f.End,
+			replacement: r.replacements[f.Pattern],
+			variant:     r.names[f.Pattern],
+		}
+	}
+	return matches
+}
+
+// htmlEntityStyles URL 中的 . / : 常见的 HTML 实体写法：十进制、大写和小写十六进制的数字实体，以及命名实体
+var htmlEntityStyles = []map[rune]string{
+	{'.': "&#46;", '/': "&#47;", ':': "&#58;"},
+	{'.': "&#x2E;", '/': "&#x2F;", ':': "&#x3A;"},
+	{'.': "&#x2e;", '/': "&#x2f;", ':': "&#x3a;"},
+	{'.': "&period;", '/': "&sol;", ':': "&colon;"},
+}
+
+// htmlEncodings 返回 HTML 写法的编码函数：只转义 <>&'" 的写法，以及每种实体写法下
+// 只编码斜杠（如 OWASP 推荐的 &#x2F;）和同时编码 . / : 的写法
+func htmlEncodings() []func(string) string {
+	encodings := []func(string) string{html.EscapeString}
+	for _, style := range htmlEntityStyles {
+		encodings = append(encodings,
+			htmlEntities(map[rune]string{'/': style['/']}),
+			htmlEntities(style),
+		)
+	}
+	return encodings
+}
+
+// htmlEntities 返回先按 html.EscapeString 转义、再将指定字符写作实体的编码函数
+func htmlEntities(entities map[rune]string) func(string) string {
+	return func(s string) string {
+		var b strings.Builder
+		for _, c := range html.EscapeString(s) {
+			if entity, ok := entities[c]; ok {
+				b.WriteString(entity)
+				continue
+			}
+			b.WriteRune(c)
+		}
+		return b.String()
+	}
+}
+
+// jsonEscape 按 JSON 字符串的转义规则编码，斜杠写作 \/
+func jsonEscape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '/':
+			b.WriteString(`\/`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, c)
+				continue
+			}
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// jsRegexEscape 按 JS 正则字面量的写法转义，斜杠写作 \/
+func jsRegexEscape(s string) string {
+	return strings.ReplaceAll(regexp.QuoteMeta(s), "/", `\/`)
+}
+
+// lowerPercent 将编码函数生成的百分号编码改为小写十六进制，如 %3A 改为 %3a
+func lowerPercent(encode func(string) string) func(string) string {
+	return func(s string) string {
+		b := []byte(encode(s))
+		for i := 0; i+2 < len(b); i++ {
+			if b[i] == '%' {
+				b[i+1] = toLowerHex(b[i+1])
+				b[i+2] = toLowerHex(b[i+2])
+				i += 2
+			}
+		}
+		return string(b)
+	}
+}
+
+// toLowerHex 将大写的十六进制字母转为小写
+func toLowerHex(c byte) byte {
+	if c >= 'A' && c <= 'F' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
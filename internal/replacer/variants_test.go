@@ -0,0 +1,34 @@
+package replacer
+
+import (
+	"testing"
+
+	"github.com/yourusername/file-replacer/internal/config"
+)
+
+// TestHTMLVariants 检查 html 写法匹配 . / : 的数字实体和命名实体，并用同样的写法替换
+func TestHTMLVariants(t *testing.T) {
+	rules, err := compileRules([]config.ReplaceItem{{
+		SearchString:  "https://qqt.cmicrwx.cn/path",
+		ReplaceString: "https://qqt.cmicvip.cn/path",
+		Variants:      []string{config.VariantHTML},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"https://qqt.cmicrwx.cn/path?a=1&amp;b=2":                     "https://qqt.cmicvip.cn/path?a=1&amp;b=2",
+		"https:&#x2F;&#x2F;qqt.cmicrwx.cn&#x2F;path":                  "https:&#x2F;&#x2F;qqt.cmicvip.cn&#x2F;path",
+		"https:&#47;&#47;qqt.cmicrwx.cn&#47;path":                     "https:&#47;&#47;qqt.cmicvip.cn&#47;path",
+		"https&#x3a;&#x2f;&#x2f;qqt&#x2e;cmicrwx&#x2e;cn&#x2f;path":   "https&#x3a;&#x2f;&#x2f;qqt&#x2e;cmicvip&#x2e;cn&#x2f;path",
+		"https&colon;&sol;&sol;qqt&period;cmicrwx&period;cn&sol;path": "https&colon;&sol;&sol;qqt&period;cmicvip&period;cn&sol;path",
+		"https&#58;&#47;&#47;qqt&#46;cmicrwx&#46;cn&#47;path":         "https&#58;&#47;&#47;qqt&#46;cmicvip&#46;cn&#47;path",
+	}
+	for content, want := range tests {
+		got, _ := applyOneByOne(rules, content)
+		if got != want {
+			t.Errorf("%q 替换后为 %q，应为 %q", content, got, want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package replacer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yourusername/file-replacer/internal/config"
+)
+
+// tokenClass 判断字符是否属于单词的一部分
+type tokenClass func(c rune) bool
+
+// newTokenClass 根据 token_chars 选项创建字符类：identifier（默认，字母、数字和下划线）、
+// hostname（再加上连字符和点号），或 "[...]" 形式的自定义正则字符类
+func newTokenClass(chars string) (tokenClass, error) {
+	switch chars {
+	case "", config.TokenIdentifier:
+		return isIdentifierChar, nil
+	case config.TokenHostname:
+		return func(c rune) bool {
+			return isIdentifierChar(c) || c == '-' || c == '.'
+		}, nil
+	}
+
+	if !strings.HasPrefix(chars, "[") || !strings.HasSuffix(chars, "]") {
+		return nil, fmt.Errorf("单词字符 '%s' 无效（可选 identifier、hostname 或 \"[...]\" 形式的字符类）", chars)
+	}
+	re, err := regexp.Compile("^" + chars + "$")
+	if err != nil {
+		return nil, fmt.Errorf("单词字符 '%s' 无效: %v", chars, err)
+	}
+	return func(c rune) bool {
+		return re.MatchString(string(c))
+	}, nil
+}
+
+// isIdentifierChar 判断字符是否可以出现在标识符中
+func isIdentifierChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_'
+}
+
+// wordRule 只保留两侧都不是单词字符的匹配，如查找 id 时不会匹配 valid 中的 id
+type wordRule struct {
+	rule
+	token tokenClass
+}
+
+func (r *wordRule) findAll(content string) []match {
+	// 字面规则在匹配被排除后从下一个字节继续查找，不会漏掉与之重叠的完整单词
+	if lit, ok := r.rule.(*literalRule); ok {
+		return r.findLiteral(content, lit.cfg)
+	}
+
+	var matches []match
+	for _, m := range r.rule.findAll(content) {
+		if r.bounded(content, m.start, m.end) {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// findLiteral 查找两侧都是单词边界的字面匹配
+func (r *wordRule) findLiteral(content string, item config.ReplaceItem) []match {
+	var matches []match
+	search := item.SearchString
+	offset := 0
+	for offset <= len(content) {
+		idx := strings.Index(content[offset:], search)
+		if idx < 0 {
+			break
+		}
+		start := offset + idx
+		end := start + len(search)
+		if !r.bounded(content, start, end) {
+			offset = start + 1
+			continue
+		}
+		matches = append(matches, match{start: start, end: end, replacement: item.ReplaceString})
+		offset = end
+	}
+	return matches
+}
+
+// bounded 判断 [start, end) 两侧是否为单词边界：匹配文本边缘的字符是单词字符时，
+// 与之相邻的字符不能也是单词字符
+func (r *wordRule) bounded(content string, start, end int) bool {
+	if start > 0 {
+		before, _ := utf8.DecodeLastRuneInString(content[:start])
+		first, _ := utf8.DecodeRuneInString(content[start:end])
+		if r.token(before) && r.token(first) {
+			return false
+		}
+	}
+	if end < len(content) {
+		after, _ := utf8.DecodeRuneInString(content[end:])
+		last, _ := utf8.DecodeLastRuneInString(content[start:end])
+		if r.token(after) && r.token(last) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package replacer
+
+import (
+	"fmt"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/fsutil"
+	"github.com/yourusername/file-replacer/internal/journal"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// openJournal 根据配置创建本次运行的记录，预览模式或未开启时返回 nil
+func openJournal(cfg *config.Config) (*journal.Journal, error) {
+	if cfg.DryRun || !cfg.Journal {
+		return nil, nil
+	}
+
+	j, err := journal.Create(cfg.JournalDir, cfg.Roots())
+	if err != nil {
+		return nil, fmt.Errorf("创建运行记录失败: %v", err)
+	}
+	return j, nil
+}
+
+// closeJournal 结束运行记录，并提示撤销方式
+func closeJournal(j *journal.Journal) {
+	if j == nil {
+		return
+	}
+
+	count := j.Count()
+	if err := j.Close(); err != nil {
+		logger.Log.Warnf("关闭运行记录失败: %v", err)
+		return
+	}
+	if count > 0 {
+		logger.Log.Infof("本次运行编号 %s，已备份 %d 个文件，可使用 \"file-replacer undo %s\" 撤销",
+			j.ID(), count, j.ID())
+	}
+}
+
+// writeFile 先备份原始内容再以原子方式写入新内容
+func writeFile(cfg *config.Config, j *journal.Journal, filePath string, original []byte, updated string) error {
+	// 备份和写入都只读取内容，不复制
+	data := stringToBytes(updated)
+	if j != nil {
+		if err := j.Record(filePath, original, data); err != nil {
+			return fmt.Errorf("备份原始内容失败: %v", err)
+		}
+	}
+	return fsutil.WriteFileAtomic(filePath, data, cfg.KeepMtime)
+}
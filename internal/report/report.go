@@ -0,0 +1,179 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/replacer"
+)
+
+// 支持的报告格式
+const FormatJSON = "json"
+
+// Target 报告的输出目标
+type Target struct {
+	Format string
+	Path   string
+}
+
+// ParseTarget 解析 "格式=路径" 形式的报告参数，如 "json=report.json"
+func ParseTarget(value string) (*Target, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("报告参数格式应为 \"格式=路径\"，如 json=report.json: %s", value)
+	}
+	format := strings.ToLower(strings.TrimSpace(parts[0]))
+	if format != FormatJSON {
+		return nil, fmt.Errorf("不支持的报告格式: %s", parts[0])
+	}
+	return &Target{Format: format, Path: parts[1]}, nil
+}
+
+// Report 一次运行的结构化报告
+type Report struct {
+	Config     *config.Config `json:"config"`
+	StartedAt  time.Time      `json:"started_at"`
+	DurationMs int64          `json:"duration_ms"`
+	Totals     Totals         `json:"totals"`
+	// 扫描多个根目录时每个根目录的汇总数据
+	Roots   []RootTotals `json:"roots,omitempty"`
+	Rules   []RuleCount  `json:"rules"`
+	Files   []FileResult `json:"files"`
+	Skipped []string     `json:"skipped"`
+	// 被中断时为 true，Unprocessed 列出已扫描但未处理的文件，中断时尚未扫描到的文件不在其中
+	Interrupted bool     `json:"interrupted"`
+	Unprocessed []string `json:"unprocessed"`
+}
+
+// Totals 汇总数据
+type Totals struct {
+	Files    int `json:"files"`
+	Matched  int `json:"matched"`
+	Modified int `json:"modified"`
+	Replaced int `json:"replaced"`
+	Skipped  int `json:"skipped"`
+	Failed   int `json:"failed"`
+}
+
+// RootTotals 单个根目录的汇总数据
+type RootTotals struct {
+	Root string `json:"root"`
+	Totals
+}
+
+// RuleCount 单个替换项的匹配数
+type RuleCount struct {
+	Rule    int    `json:"rule"`
+	Type    string `json:"type"`
+	Search  string `json:"search"`
+	Replace string `json:"replace"`
+	Count   int    `json:"count"`
+	// 区分写法的替换项（如标识符规则）中各写法的匹配数
+	Variants map[string]int `json:"variants,omitempty"`
+}
+
+// FileResult 单个文件的结果
+type FileResult struct {
+	Path     string      `json:"path"`
+	Replaced int         `json:"replaced"`
+	Modified bool        `json:"modified"`
+	Rules    []RuleCount `json:"rules,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// New 根据配置和替换汇总生成报告
+func New(cfg *config.Config, summary *replacer.Summary) *Report {
+	r := &Report{
+		Config:     cfg,
+		StartedAt:  summary.Started,
+		DurationMs: summary.Duration.Milliseconds(),
+		Totals: Totals{
+			Files:    summary.Files,
+			Matched:  summary.Matched,
+			Modified: summary.Modified,
+			Replaced: summary.Replaced,
+			Skipped:  summary.Skipped,
+			Failed:   summary.Failed,
+		},
+		Rules:       ruleCounts(cfg.ReplaceItems, summary.RuleCounts, summary.VariantCounts, false),
+		Files:       []FileResult{},
+		Skipped:     []string{},
+		Interrupted: summary.Interrupted,
+		Unprocessed: append([]string{}, summary.Unprocessed...),
+	}
+	sort.Strings(r.Unprocessed)
+
+	for _, root := range summary.Roots {
+		r.Roots = append(r.Roots, RootTotals{
+			Root: root.Root,
+			Totals: Totals{
+				Files:    root.Files,
+				Matched:  root.Matched,
+				Modified: root.Modified,
+				Replaced: root.Replaced,
+				Skipped:  root.Skipped,
+				Failed:   root.Failed,
+			},
+		})
+	}
+
+	for _, result := range summary.Results {
+		if result.Skipped {
+			r.Skipped = append(r.Skipped, result.FilePath)
+			continue
+		}
+
+		file := FileResult{
+			Path:     result.FilePath,
+			Replaced: result.Replaced,
+			Modified: result.ContentModified,
+			Rules:    ruleCounts(cfg.ReplaceItems, result.RuleCounts, result.VariantCounts, true),
+		}
+		if result.Error != nil {
+			file.Error = result.Error.Error()
+		}
+		r.Files = append(r.Files, file)
+	}
+	return r
+}
+
+// ruleCounts 将按替换项下标排列的匹配数及各写法的匹配数转换为报告格式，omitZero 为 true 时省略没有匹配的项
+func ruleCounts(items []config.ReplaceItem, counts []int, variants map[int]map[string]int, omitZero bool) []RuleCount {
+	var result []RuleCount
+	for i, item := range items {
+		count := 0
+		if i < len(counts) {
+			count = counts[i]
+		}
+		if omitZero && count == 0 {
+			continue
+		}
+		ruleType := item.Type
+		if ruleType == "" {
+			ruleType = config.RuleLiteral
+		}
+		result = append(result, RuleCount{
+			Rule:     i + 1,
+			Type:     ruleType,
+			Search:   item.SearchString,
+			Replace:  item.ReplaceString,
+			Count:    count,
+			Variants: variants[i],
+		})
+	}
+	return result
+}
+
+// Write 将报告写入目标文件
+func (r *Report) Write(target *Target) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(target.Path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// pathFilter 按 glob 模式筛选相对根目录的路径，支持 ** 和 {a,b}
+type pathFilter struct {
+	include []string
+	exclude []string
+}
+
+// newPathFilter 校验并创建路径筛选器
+func newPathFilter(include, exclude []string) (*pathFilter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("无效的匹配模式: %s", pattern)
+		}
+	}
+	return &pathFilter{include: include, exclude: exclude}, nil
+}
+
+// excludeDir 判断目录是否被排除，被排除的目录不再向下遍历
+func (f *pathFilter) excludeDir(rel string) bool {
+	return matchAny(f.exclude, rel)
+}
+
+// acceptFile 判断文件是否应被处理：未被排除，且在指定了 include 时至少匹配其中一个
+func (f *pathFilter) acceptFile(rel string) bool {
+	if matchAny(f.exclude, rel) {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	return matchAny(f.include, rel)
+}
+
+// matchAny 判断相对路径是否匹配任一模式
+func matchAny(patterns []string, rel string) bool {
+	name := filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if doublestar.MatchUnvalidated(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// walkGit 从 git 读取规则所属根目录下的文件列表，按与遍历目录相同的规则筛选后交给 emit。
+// 已删除的文件和子模块会被跳过
+func (s *FileScanner) walkGit(ctx context.Context, rules *pathRules, emit func(path string) error) error {
+	logger.Log.Infof("开始从 git 读取文件列表 (%s): %s", s.config.GitFiles, rules.root)
+
+	paths, err := gitFileList(ctx, rules.root, s.config.GitFiles, s.config.GitSince)
+	if err != nil {
+		return err
+	}
+	logger.Log.Infof("git 列出 %d 个文件", len(paths))
+
+	// 检查过的目录是否被跳过，键为相对根目录的路径
+	skipped := make(map[string]bool)
+	for _, rel := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := filepath.Join(rules.root, filepath.FromSlash(rel))
+
+		skip, err := s.skipParents(rules, rel, skipped)
+		if err != nil {
+			return err
+		}
+		if skip {
+			logger.Log.Debugf("跳过文件: %s", path)
+			continue
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				logger.Log.Debugf("文件已删除，跳过: %s", path)
+				continue
+			}
+			if err := s.accessError(path, false, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if info.IsDir() {
+			logger.Log.Debugf("跳过子模块: %s", path)
+			continue
+		}
+
+		if rules.skipFile(path, filepath.FromSlash(rel)) {
+			logger.Log.Debugf("跳过文件: %s", path)
+			continue
+		}
+		if err := emit(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipParents 从根目录开始依次检查文件所在的各级目录，任一目录被跳过时返回 true。
+// 首次检查某个目录时加载其中的忽略文件，结果记录在 skipped 中
+func (s *FileScanner) skipParents(rules *pathRules, rel string, skipped map[string]bool) (bool, error) {
+	dirs := []string{"."}
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' {
+			dirs = append(dirs, rel[:i])
+		}
+	}
+
+	for _, dir := range dirs {
+		skip, ok := skipped[dir]
+		if !ok {
+			dirPath := filepath.Join(rules.root, filepath.FromSlash(dir))
+			dirRel := filepath.FromSlash(dir)
+			skip = rules.skipDir(dirPath, dirRel)
+			if skip {
+				logger.Log.Debugf("忽略目录: %s", dirPath)
+			} else if err := rules.enterDir(dirPath, dirRel); err != nil {
+				// 与遍历目录时相同，无法读取忽略规则的目录整个跳过
+				if err := s.accessError(dirPath, true, err); err != filepath.SkipDir {
+					return false, err
+				}
+				skip = true
+			}
+			skipped[dir] = skip
+		}
+		if skip {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// gitFileList 调用 git 列出根目录下按指定方式选择的文件，返回相对根目录、使用 / 分隔的路径
+func gitFileList(ctx context.Context, root, mode, since string) ([]string, error) {
+	var args []string
+	switch mode {
+	case config.GitTracked:
+		args = []string{"ls-files", "-z"}
+	case config.GitStaged:
+		args = []string{"diff", "--cached", "--name-only", "-z", "--diff-filter=d", "--relative"}
+	case config.GitChanged:
+		// 先解析为提交，--end-of-options 保证 since 不会被当作选项
+		commit, err := runGit(ctx, root, "rev-parse", "--verify", "--end-of-options", since+"^{commit}")
+		if err != nil {
+			return nil, fmt.Errorf("无法将 '%s' 解析为提交: %v", since, err)
+		}
+		// 与分叉点比较，只包含当前分支的改动，不包含 since 分支之后的提交
+		base, err := runGit(ctx, root, "merge-base", strings.TrimSpace(string(commit)), "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		args = []string{"diff", "--name-only", "-z", "--diff-filter=d", "--relative", strings.TrimSpace(string(base))}
+	default:
+		return nil, fmt.Errorf("未知的 git 文件选择方式: %s", mode)
+	}
+
+	out, err := runGit(ctx, root, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(string(out), "\x00") {
+		// 有冲突的文件在 ls-files 的输出中会出现多次
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		paths = append(paths, path.Clean(name))
+	}
+	return paths, nil
+}
+
+// runGit 在 dir 目录中执行 git 命令并返回标准输出，失败时错误中包含 git 的错误输出
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("未找到 git 命令: %v", err)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s 失败: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s 失败: %v", args[0], err)
+	}
+	return out, nil
+}
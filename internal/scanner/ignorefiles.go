@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/file-replacer/internal/ignore"
+)
+
+const (
+	// gitDirName git 仓库目录的名称，启用 .gitignore 时始终跳过
+	gitDirName = ".git"
+	// gitignoreName git 的忽略文件
+	gitignoreName = ".gitignore"
+	// replacerignoreName 本工具的忽略文件，语法与 .gitignore 相同，总是生效
+	replacerignoreName = ".replacerignore"
+)
+
+// ignoreFiles 读取扫描时进入的每个目录中的忽略文件（如 .gitignore），判断路径是否被忽略。
+// 忽略文件中的规则只作用于所在目录下的路径
+type ignoreFiles struct {
+	// 忽略文件的名称
+	name    string
+	matcher *ignore.Matcher
+	// 扫描根目录相对规则根目录（如仓库根目录）的路径（使用 /，两者相同时为 ""）
+	rootRel string
+}
+
+// newReplacerignore 创建读取 .replacerignore 的规则集，规则根目录为扫描根目录
+func newReplacerignore() *ignoreFiles {
+	return &ignoreFiles{name: replacerignoreName, matcher: ignore.New()}
+}
+
+// newGitignore 按 git 的规则创建规则集：从扫描根目录向上查找仓库根目录（包含 .git 的目录），
+// 找不到时以扫描根目录为准，并加载仓库的 .git/info/exclude 和扫描根目录以上各级的 .gitignore。
+// 扫描根目录自身的 .gitignore 在进入该目录时加载
+func newGitignore(root string) (*ignoreFiles, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	top := findRepoRoot(abs)
+
+	g := &ignoreFiles{name: gitignoreName, matcher: ignore.New()}
+	// .git 目录始终忽略，优先级低于所有忽略文件
+	g.matcher.AddPatterns("", []string{gitDirName})
+	if err := g.matcher.AddFile("", filepath.Join(top, gitDirName, "info", "exclude")); err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return nil, err
+	}
+	if rel == "." {
+		return g, nil
+	}
+	g.rootRel = filepath.ToSlash(rel)
+
+	// 加载仓库根目录到扫描根目录的上一级目录中的 .gitignore
+	dir := ""
+	for _, name := range strings.Split(g.rootRel, "/") {
+		file := filepath.Join(top, filepath.FromSlash(dir), gitignoreName)
+		if err := g.matcher.AddFile(dir, file); err != nil {
+			return nil, err
+		}
+		dir = path.Join(dir, name)
+	}
+	return g, nil
+}
+
+// findRepoRoot 从 dir 开始向上查找包含 .git 的目录，找不到时返回 dir
+func findRepoRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, gitDirName)); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+// repoPath 将相对扫描根目录的路径转换为相对规则根目录的路径
+func (g *ignoreFiles) repoPath(rel string) string {
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return g.rootRel
+	}
+	return path.Join(g.rootRel, rel)
+}
+
+// ignored 判断相对扫描根目录的路径是否被忽略
+func (g *ignoreFiles) ignored(rel string, isDir bool) bool {
+	return g.matcher.Match(g.repoPath(rel), isDir)
+}
+
+// enterDir 加载目录中的忽略文件
+func (g *ignoreFiles) enterDir(dir, rel string) error {
+	return g.matcher.AddFile(g.repoPath(rel), filepath.Join(dir, g.name))
+}
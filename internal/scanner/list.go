@@ -0,0 +1,175 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/file-replacer/pkg/logger"
+)
+
+// StdinList FilesFrom 为该值时从标准输入读取文件列表
+const StdinList = "-"
+
+// listRoot 按文件列表处理时一个根目录的筛选规则
+type listRoot struct {
+	// 根目录的绝对路径
+	abs   string
+	rules *pathRules
+	// 检查过的目录是否被跳过，键为相对根目录的路径
+	skipped map[string]bool
+}
+
+// walkList 从 FilesFrom 指定的文件（或标准输入）读取文件列表，逐个交给 emit，不遍历根目录。
+// 列表中的相对路径相对当前目录；FilesFromFilter 为 true 时按文件所在根目录的规则筛选，
+// 此时不在任何根目录下的文件会被跳过。运行记录目录中的文件总是跳过
+func (s *FileScanner) walkList(ctx context.Context, emit func(path string) error) error {
+	source := s.config.FilesFrom
+	var r io.Reader = os.Stdin
+	if source == StdinList {
+		logger.Log.Info("开始从标准输入读取文件列表")
+	} else {
+		logger.Log.Infof("开始从文件读取文件列表: %s", source)
+		f, err := os.Open(source)
+		if err != nil {
+			return fmt.Errorf("打开文件列表失败: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var roots []*listRoot
+	if s.config.FilesFromFilter {
+		for _, root := range s.config.Roots() {
+			abs, err := filepath.Abs(root)
+			if err != nil {
+				return err
+			}
+			rules, err := s.newPathRules(root)
+			if err != nil {
+				return err
+			}
+			roots = append(roots, &listRoot{abs: abs, rules: rules, skipped: make(map[string]bool)})
+		}
+	}
+
+	seen := make(map[string]bool)
+
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	lines.Split(new(listSplitter).split)
+	for lines.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := strings.TrimSuffix(lines.Text(), "\r")
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		if s.inJournalDir(path) {
+			logger.Log.Debugf("跳过运行记录目录中的文件: %s", path)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if err := s.accessError(path, false, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if info.IsDir() {
+			logger.Log.Warnf("文件列表中的 %s 是目录，已跳过", path)
+			continue
+		}
+
+		if s.config.FilesFromFilter {
+			skip, err := s.skipListed(roots, path)
+			if err != nil {
+				return err
+			}
+			if skip {
+				logger.Log.Debugf("跳过文件: %s", path)
+				continue
+			}
+		}
+		if err := emit(path); err != nil {
+			return err
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("读取文件列表失败: %v", err)
+	}
+	return nil
+}
+
+// skipListed 按文件所在根目录的规则判断列表中的文件是否应跳过，不在任何根目录下的文件总是跳过
+func (s *FileScanner) skipListed(roots []*listRoot, path string) (bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	for _, root := range roots {
+		rel, err := filepath.Rel(root.abs, abs)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		skip, err := s.skipParents(root.rules, filepath.ToSlash(rel), root.skipped)
+		if err != nil || skip {
+			return skip, err
+		}
+		return root.rules.skipFile(filepath.Join(root.rules.root, rel), rel), nil
+	}
+	logger.Log.Debugf("文件 %s 不在根目录下", path)
+	return true, nil
+}
+
+// inJournalDir 判断文件是否位于运行记录目录中
+func (s *FileScanner) inJournalDir(path string) bool {
+	if s.journalDir == "" {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(abs, s.journalDir+string(filepath.Separator))
+}
+
+// listSplitter 拆分文件列表：读到的第一段内容中含有 NUL 时按 NUL 分隔（如 find -print0、
+// git ls-files -z 的输出），否则按换行分隔
+type listSplitter struct {
+	decided bool
+	sep     byte
+}
+
+func (l *listSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	if !l.decided {
+		switch {
+		case bytes.IndexByte(data, 0) >= 0:
+			l.sep = 0
+		case bytes.IndexByte(data, '\n') >= 0 || atEOF:
+			l.sep = '\n'
+		default:
+			// 还没有读到分隔符，继续读取
+			return 0, nil, nil
+		}
+		l.decided = true
+	}
+
+	if i := bytes.IndexByte(data, l.sep); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -1,68 +1,308 @@
 package scanner
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/yourusername/file-replacer/internal/config"
+	"github.com/yourusername/file-replacer/internal/failures"
 	"github.com/yourusername/file-replacer/pkg/logger"
 )
 
+// Stream 返回的文件通道的缓冲大小
+const streamBuffer = 256
+
 // FileScanner 文件扫描器
 type FileScanner struct {
 	config *config.Config
 	files  []string
+	// 运行记录目录的绝对路径，扫描时始终跳过，避免改写备份
+	journalDir string
+	// 扫描过程中无法访问的路径
+	failures failures.List
 }
 
 // NewFileScanner 创建新的文件扫描器
 func NewFileScanner(cfg *config.Config) *FileScanner {
-	return &FileScanner{
+	s := &FileScanner{
 		config: cfg,
 		files:  make([]string, 0),
 	}
+	if cfg.JournalDir != "" {
+		if abs, err := filepath.Abs(cfg.JournalDir); err == nil {
+			s.journalDir = abs
+		}
+	}
+	return s
+}
+
+// Failures 返回扫描过程中无法访问的路径
+func (s *FileScanner) Failures() []failures.Failure {
+	return s.failures.Failures()
+}
+
+// CheckRoots 检查各根目录能否访问，以便在开始替换前发现无法扫描的根目录。
+// 从文件列表读取且不按根目录筛选时不使用根目录，不做检查
+func (s *FileScanner) CheckRoots() error {
+	if s.config.FilesFrom != "" && !s.config.FilesFromFilter {
+		return nil
+	}
+	for _, root := range s.config.Roots() {
+		if _, err := os.Stat(root); err != nil {
+			return fmt.Errorf("无法访问根目录 %s: %v", root, err)
+		}
+	}
+	return nil
 }
 
 // Scan 扫描目录下的所有文件
+// 根目录无法访问时总是返回错误；其他路径出错时按出错处理策略决定：
+// fail-fast 立即中止，continue 记录后跳过，collect 跳过并在返回文件列表的同时返回汇总错误
 func (s *FileScanner) Scan() ([]string, error) {
-	logger.Log.Infof("开始扫描目录: %s", s.config.RootDir)
+	s.files = s.files[:0]
+	err := s.walk(context.Background(), func(path string) error {
+		s.files = append(s.files, path)
+		return nil
+	})
+	if err != nil {
+		var collected *failures.Error
+		if !errors.As(err, &collected) {
+			return nil, err
+		}
+	}
+	return s.files, err
+}
 
-	err := filepath.Walk(s.config.RootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			logger.Log.Errorf("访问路径 %s 时出错: %v", path, err)
+// Stream 在后台扫描目录，每发现一个文件立即发送到返回的文件通道，扫描结束后关闭该通道，
+// 使替换可以与扫描同时进行。文件通道关闭后，错误通道会收到扫描结果（无错误时为 nil），
+// 其含义与 Scan 返回的错误相同。ctx 被取消时扫描提前结束
+func (s *FileScanner) Stream(ctx context.Context) (<-chan string, <-chan error) {
+	files := make(chan string, streamBuffer)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		err := s.walk(ctx, func(path string) error {
+			select {
+			case files <- path:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(files)
+		errc <- err
+	}()
+
+	return files, errc
+}
+
+// walk 依次遍历各根目录，或从指定的文件列表、git 读取文件，对每个通过筛选的文件调用 emit
+func (s *FileScanner) walk(ctx context.Context, emit func(path string) error) error {
+	count := 0
+	found := func(path string) error {
+		logger.Log.Debugf("找到文件: %s", path)
+		count++
+		return emit(path)
+	}
+
+	var err error
+	if s.config.FilesFrom != "" {
+		err = s.walkList(ctx, found)
+	} else {
+		for _, root := range s.config.Roots() {
+			var rules *pathRules
+			if rules, err = s.newPathRules(root); err != nil {
+				break
+			}
+			if s.config.GitFiles != "" {
+				err = s.walkGit(ctx, rules, found)
+			} else {
+				err = s.walkDir(ctx, rules, found)
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			logger.Log.Warnf("扫描已中断，已发现 %d 个文件", count)
 			return err
 		}
+		logger.Log.Errorf("扫描过程中出错: %v", err)
+		return err
+	}
+
+	logger.Log.Infof("扫描完成，共发现 %d 个文件", count)
+	if s.config.OnError == config.OnErrorCollect {
+		return s.failures.Err()
+	}
+	return nil
+}
+
+// walkDir 遍历规则所属根目录下的所有文件
+func (s *FileScanner) walkDir(ctx context.Context, rules *pathRules, emit func(path string) error) error {
+	logger.Log.Infof("开始扫描目录: %s", rules.root)
+
+	return filepath.Walk(rules.root, func(path string, info os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return s.accessError(path, info != nil && info.IsDir(), err)
+		}
+
+		rel, err := filepath.Rel(rules.root, path)
+		if err != nil {
+			return fmt.Errorf("计算相对路径 %s 失败: %v", path, err)
+		}
+		// 根目录本身是文件时按文件名匹配
+		if rel == "." && !info.IsDir() {
+			rel = filepath.Base(path)
+		}
 
 		// 检查是否为目录
 		if info.IsDir() {
 			// 检查是否应该忽略该目录
-			if s.shouldIgnoreDir(path) {
+			if rules.skipDir(path, rel) {
 				logger.Log.Debugf("忽略目录: %s", path)
 				return filepath.SkipDir
 			}
+			if err := rules.enterDir(path, rel); err != nil {
+				// 无法读取目录的忽略规则时跳过整个目录，避免改写本应忽略的文件
+				return s.accessError(path, true, err)
+			}
 			return nil
 		}
 
-		// 将文件添加到扫描列表
-		s.files = append(s.files, path)
-		logger.Log.Debugf("找到文件: %s", path)
-		return nil
+		// 按忽略文件和 include/exclude 模式筛选文件
+		if rules.skipFile(path, rel) {
+			logger.Log.Debugf("跳过文件: %s", path)
+			return nil
+		}
+
+		// 将文件交给调用方
+		return emit(path)
 	})
+}
+
+// pathRules 一个根目录下判断目录和文件是否应跳过的规则：忽略目录列表、include/exclude 模式，
+// 以及各目录中的 .replacerignore 和（启用时的）.gitignore
+type pathRules struct {
+	scanner *FileScanner
+	// 规则所属的根目录，路径均相对该目录
+	root    string
+	filter  *pathFilter
+	ignores []*ignoreFiles
+}
 
+// newPathRules 按配置创建根目录的筛选规则
+func (s *FileScanner) newPathRules(root string) (*pathRules, error) {
+	filter, err := newPathFilter(s.config.Include, s.config.Exclude)
 	if err != nil {
-		logger.Log.Errorf("扫描过程中出错: %v", err)
 		return nil, err
 	}
 
-	logger.Log.Infof("扫描完成，共发现 %d 个文件", len(s.files))
-	return s.files, nil
+	// 各目录中的 .replacerignore 总是生效，.gitignore 按配置启用
+	rules := &pathRules{scanner: s, root: root, filter: filter, ignores: []*ignoreFiles{newReplacerignore()}}
+	if s.config.RespectGitignore {
+		git, err := newGitignore(root)
+		if err != nil {
+			return nil, fmt.Errorf("读取 .gitignore 规则失败: %v", err)
+		}
+		rules.ignores = append(rules.ignores, git)
+	}
+	return rules, nil
+}
+
+// skipDir 判断目录是否应跳过，被跳过的目录不再向下遍历
+func (r *pathRules) skipDir(path, rel string) bool {
+	if r.scanner.shouldIgnoreDir(path, rel) {
+		return true
+	}
+	return rel != "." && (r.filter.excludeDir(rel) || r.ignored(rel, true))
+}
+
+// enterDir 加载目录中的忽略文件，应在检查该目录下的路径之前调用
+func (r *pathRules) enterDir(path, rel string) error {
+	for _, g := range r.ignores {
+		if err := g.enterDir(path, rel); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// shouldIgnoreDir 检查是否应该忽略该目录
-func (s *FileScanner) shouldIgnoreDir(path string) bool {
+// skipFile 判断文件是否应跳过：被忽略文件排除，或不符合 include/exclude 模式。
+// 根目录本身是文件时不检查忽略文件
+func (r *pathRules) skipFile(path, rel string) bool {
+	if path != r.root && r.ignored(rel, false) {
+		return true
+	}
+	return !r.filter.acceptFile(rel)
+}
+
+// ignored 判断路径是否被任一忽略文件排除
+func (r *pathRules) ignored(rel string, isDir bool) bool {
+	for _, g := range r.ignores {
+		if g.ignored(rel, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// accessError 按出错处理策略处理无法访问的路径：根目录出错或 fail-fast 时返回错误，
+// 否则记录后跳过该路径
+func (s *FileScanner) accessError(path string, isDir bool, err error) error {
+	if s.isRoot(path) || s.config.OnError == config.OnErrorFailFast {
+		logger.Log.Errorf("访问路径 %s 时出错: %v", path, err)
+		return err
+	}
+	logger.Log.Warnf("访问路径 %s 时出错，已跳过: %v", path, err)
+	s.failures.Add(path, err)
+	if isDir {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// isRoot 判断路径是否为某个根目录
+func (s *FileScanner) isRoot(path string) bool {
+	for _, root := range s.config.Roots() {
+		if path == root {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldIgnoreDir 检查是否应该忽略该目录。忽略列表中不含 / 的项按目录名匹配任意层级的目录，
+// 含 / 的项按 glob 模式匹配相对根目录的路径，两者都不区分大小写
+func (s *FileScanner) shouldIgnoreDir(path, rel string) bool {
+	if s.journalDir != "" {
+		if abs, err := filepath.Abs(path); err == nil && abs == s.journalDir {
+			return true
+		}
+	}
+
 	dir := filepath.Base(path)
+	relPath := strings.ToLower(filepath.ToSlash(rel))
 	for _, ignoreDir := range s.config.IgnoreDirs {
-		if strings.EqualFold(dir, ignoreDir) {
+		if !strings.Contains(ignoreDir, "/") {
+			if strings.EqualFold(dir, ignoreDir) {
+				return true
+			}
+			continue
+		}
+		if rel != "." && doublestar.MatchUnvalidated(strings.ToLower(ignoreDir), relPath) {
 			return true
 		}
 	}